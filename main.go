@@ -2,6 +2,7 @@ package main
 
 import (
 	"flag"
+	"fmt"
 	"os"
 	"os/signal"
 
@@ -33,11 +34,19 @@ func main() {
 		panic(err)
 	}
 
-	client, err := fitbit.NewClient(db, conf.Fitbit.ClientID, conf.Fitbit.ClientSecret)
+	client, err := fitbit.NewClient(db, conf)
 	if err != nil {
 		panic(err)
 	}
 
+	if flag.NArg() > 0 {
+		if err := runCommand(client, flag.Args()); err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(1)
+		}
+		return
+	}
+
 	exporter := exporter.New(conf, client, db)
 	go exporter.Start()
 	defer exporter.Stop()
@@ -52,3 +61,30 @@ func main() {
 	signal.Notify(c, os.Interrupt)
 	<-c
 }
+
+// runCommand runs a one off management command instead of starting the exporter
+func runCommand(client *fitbit.Client, args []string) error {
+	switch args[0] {
+	case "remove-user":
+		cmd := flag.NewFlagSet("remove-user", flag.ExitOnError)
+		purge := cmd.Bool("purge", false, "Delete all stored data for the user")
+		cmd.Usage = func() {
+			fmt.Fprintln(cmd.Output(), "Usage: fitbit-exporter remove-user [-purge] <user id>")
+			cmd.PrintDefaults()
+		}
+		cmd.Parse(args[1:])
+		if cmd.NArg() != 1 {
+			cmd.Usage()
+			os.Exit(2)
+		}
+
+		defer client.Close()
+		if err := client.RemoveUser(cmd.Arg(0), *purge); err != nil {
+			return err
+		}
+		fmt.Printf("removed user %s\n", cmd.Arg(0))
+		return nil
+	default:
+		return fmt.Errorf("unknown command %q", args[0])
+	}
+}
@@ -0,0 +1 @@
+DROP TABLE cardio_score;
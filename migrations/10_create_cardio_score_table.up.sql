@@ -0,0 +1,12 @@
+BEGIN;
+
+CREATE TABLE IF NOT EXISTS cardio_score (
+    user_id         VARCHAR(150) NOT NULL,
+    date            DATETIME NOT NULL,
+    vo2_max_low     DOUBLE NOT NULL,
+    vo2_max_high    DOUBLE NOT NULL,
+
+    PRIMARY KEY (user_id, date)
+);
+
+COMMIT;
@@ -0,0 +1,3 @@
+DROP TABLE nutrition_daily;
+DROP TABLE water_log;
+DROP TABLE food_log;
@@ -0,0 +1,45 @@
+BEGIN;
+
+CREATE TABLE IF NOT EXISTS food_log (
+    log_id          BIGINT PRIMARY KEY,
+    user_id         VARCHAR(150) NOT NULL,
+    date            DATETIME NOT NULL,
+    name            TEXT NOT NULL,
+    brand           TEXT NOT NULL,
+    meal_type_id    INT NOT NULL,
+    amount          DOUBLE NOT NULL,
+    unit            VARCHAR(150) NOT NULL,
+    calories        DOUBLE NOT NULL,
+    carbs           DOUBLE NOT NULL,
+    fat             DOUBLE NOT NULL,
+    fiber           DOUBLE NOT NULL,
+    protein         DOUBLE NOT NULL,
+    sodium          DOUBLE NOT NULL
+);
+
+CREATE TABLE IF NOT EXISTS water_log (
+    log_id      BIGINT PRIMARY KEY,
+    user_id     VARCHAR(150) NOT NULL,
+    date        DATETIME NOT NULL,
+    amount      DOUBLE NOT NULL
+);
+
+CREATE TABLE IF NOT EXISTS nutrition_daily (
+    user_id         VARCHAR(150) NOT NULL,
+    date            DATETIME NOT NULL,
+    calories        DOUBLE NOT NULL,
+    carbs           DOUBLE NOT NULL,
+    fat             DOUBLE NOT NULL,
+    fiber           DOUBLE NOT NULL,
+    protein         DOUBLE NOT NULL,
+    sodium          DOUBLE NOT NULL,
+    water           DOUBLE NOT NULL,
+    calorie_goal    DOUBLE NOT NULL,
+
+    PRIMARY KEY (user_id, date)
+);
+
+CREATE INDEX food_log_user_date ON food_log (user_id, date);
+CREATE INDEX water_log_user_date ON water_log (user_id, date);
+
+COMMIT;
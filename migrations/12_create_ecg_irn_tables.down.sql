@@ -0,0 +1,2 @@
+DROP TABLE irn_alert;
+DROP TABLE ecg_reading;
@@ -0,0 +1,29 @@
+BEGIN;
+
+CREATE TABLE IF NOT EXISTS ecg_reading (
+    user_id             VARCHAR(150) NOT NULL,
+    start_time          DATETIME NOT NULL,
+    average_heart_rate  INT NOT NULL,
+    classification      VARCHAR(150) NOT NULL,
+    sampling_frequency  VARCHAR(150) NOT NULL,
+    scaling_factor      INT NOT NULL,
+    number_of_samples   INT NOT NULL,
+    lead_number         INT NOT NULL,
+    feature_version     VARCHAR(150) NOT NULL,
+    device_name         VARCHAR(150) NOT NULL,
+    firmware_version    VARCHAR(150) NOT NULL,
+    waveform            MEDIUMBLOB NOT NULL,
+
+    PRIMARY KEY (user_id, start_time)
+);
+
+CREATE TABLE IF NOT EXISTS irn_alert (
+    user_id         VARCHAR(150) NOT NULL,
+    alert_time      DATETIME NOT NULL,
+    detected_time   DATETIME NOT NULL,
+    service_version VARCHAR(150) NOT NULL,
+
+    PRIMARY KEY (user_id, alert_time)
+);
+
+COMMIT;
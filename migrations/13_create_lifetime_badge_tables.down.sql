@@ -0,0 +1,2 @@
+DROP TABLE badge;
+DROP TABLE lifetime_stats;
@@ -0,0 +1,32 @@
+BEGIN;
+
+CREATE TABLE IF NOT EXISTS lifetime_stats (
+    user_id         VARCHAR(150) NOT NULL,
+    date            DATETIME NOT NULL,
+    distance        DOUBLE NOT NULL,
+    floors          DOUBLE NOT NULL,
+    steps           BIGINT NOT NULL,
+    calories_out    DOUBLE NOT NULL,
+
+    PRIMARY KEY (user_id, date)
+);
+
+CREATE TABLE IF NOT EXISTS badge (
+    user_id         VARCHAR(150) NOT NULL,
+    encoded_id      VARCHAR(150) NOT NULL,
+    badge_type      VARCHAR(150) NOT NULL,
+    category        VARCHAR(150) NOT NULL,
+    name            TEXT NOT NULL,
+    short_name      TEXT NOT NULL,
+    description     TEXT NOT NULL,
+    value           INT NOT NULL,
+    times_achieved  INT NOT NULL,
+    date            DATETIME NOT NULL,
+    image           TEXT NOT NULL,
+
+    PRIMARY KEY (user_id, encoded_id)
+);
+
+CREATE INDEX badge_user_date ON badge (user_id, date);
+
+COMMIT;
@@ -0,0 +1,16 @@
+BEGIN;
+
+CREATE TABLE IF NOT EXISTS sync_state (
+    user_id         VARCHAR(150) NOT NULL,
+    data_type       VARCHAR(150) NOT NULL,
+    oldest_date     DATETIME NULL,
+    newest_date     DATETIME NULL,
+    complete        BOOLEAN NOT NULL DEFAULT FALSE,
+    last_success    DATETIME NULL,
+    last_error      TEXT NULL,
+    last_error_time DATETIME NULL,
+
+    PRIMARY KEY (user_id, data_type)
+);
+
+COMMIT;
@@ -0,0 +1,3 @@
+DROP TABLE sleep_level;
+DROP TABLE sleep_stage;
+DROP TABLE sleep_log;
@@ -0,0 +1,42 @@
+BEGIN;
+
+CREATE TABLE IF NOT EXISTS sleep_log (
+    log_id                  BIGINT PRIMARY KEY,
+    user_id                 VARCHAR(150) NOT NULL,
+    date                    DATETIME NOT NULL,
+    start_time              DATETIME NOT NULL,
+    end_time                DATETIME NOT NULL,
+    duration                INT NOT NULL,
+    efficiency              INT NOT NULL,
+    is_main_sleep           BOOLEAN NOT NULL,
+    minutes_asleep          INT NOT NULL,
+    minutes_awake           INT NOT NULL,
+    minutes_to_fall_asleep  INT NOT NULL,
+    minutes_after_wakeup    INT NOT NULL,
+    time_in_bed             INT NOT NULL,
+    type                    VARCHAR(150) NOT NULL
+);
+
+CREATE TABLE IF NOT EXISTS sleep_stage (
+    log_id                  BIGINT NOT NULL,
+    stage                   VARCHAR(150) NOT NULL,
+    count                   INT NOT NULL,
+    minutes                 INT NOT NULL,
+    thirty_day_avg_minutes  INT NOT NULL,
+
+    PRIMARY KEY (log_id, stage)
+);
+
+CREATE TABLE IF NOT EXISTS sleep_level (
+    log_id      BIGINT NOT NULL,
+    date        DATETIME NOT NULL,
+    level       VARCHAR(150) NOT NULL,
+    seconds     INT NOT NULL,
+    short       BOOLEAN NOT NULL,
+
+    PRIMARY KEY (log_id, date, short)
+);
+
+CREATE INDEX sleep_log_user_date ON sleep_log (user_id, date);
+
+COMMIT;
@@ -0,0 +1,2 @@
+DROP TABLE activity_data;
+DROP TABLE activity_daily;
@@ -0,0 +1,23 @@
+BEGIN;
+
+CREATE TABLE IF NOT EXISTS activity_daily (
+    user_id     VARCHAR(150) NOT NULL,
+    date        DATETIME NOT NULL,
+    resource    VARCHAR(150) NOT NULL,
+    value       DOUBLE NOT NULL,
+
+    PRIMARY KEY (user_id, date, resource)
+);
+
+CREATE TABLE IF NOT EXISTS activity_data (
+    user_id     VARCHAR(150) NOT NULL,
+    date        DATETIME NOT NULL,
+    resource    VARCHAR(150) NOT NULL,
+    value       DOUBLE NOT NULL,
+
+    PRIMARY KEY (user_id, resource, date)
+);
+
+CREATE INDEX activity_daily_resource ON activity_daily (resource);
+
+COMMIT;
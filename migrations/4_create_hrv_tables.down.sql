@@ -0,0 +1,2 @@
+DROP TABLE hrv_intraday;
+DROP TABLE hrv_daily;
@@ -0,0 +1,23 @@
+BEGIN;
+
+CREATE TABLE IF NOT EXISTS hrv_daily (
+    user_id     VARCHAR(150) NOT NULL,
+    date        DATETIME NOT NULL,
+    daily_rmssd DOUBLE NOT NULL,
+    deep_rmssd  DOUBLE NOT NULL,
+
+    PRIMARY KEY (user_id, date)
+);
+
+CREATE TABLE IF NOT EXISTS hrv_intraday (
+    user_id     VARCHAR(150) NOT NULL,
+    date        DATETIME NOT NULL,
+    rmssd       DOUBLE NOT NULL,
+    coverage    DOUBLE NOT NULL,
+    hf          DOUBLE NOT NULL,
+    lf          DOUBLE NOT NULL,
+
+    PRIMARY KEY (user_id, date)
+);
+
+COMMIT;
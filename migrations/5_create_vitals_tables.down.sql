@@ -0,0 +1,4 @@
+DROP TABLE skin_temp;
+DROP TABLE breathing_rate;
+DROP TABLE spo2_intraday;
+DROP TABLE spo2_daily;
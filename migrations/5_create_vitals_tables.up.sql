@@ -0,0 +1,38 @@
+BEGIN;
+
+CREATE TABLE IF NOT EXISTS spo2_daily (
+    user_id     VARCHAR(150) NOT NULL,
+    date        DATETIME NOT NULL,
+    avg         DOUBLE NOT NULL,
+    min         DOUBLE NOT NULL,
+    max         DOUBLE NOT NULL,
+
+    PRIMARY KEY (user_id, date)
+);
+
+CREATE TABLE IF NOT EXISTS spo2_intraday (
+    user_id     VARCHAR(150) NOT NULL,
+    date        DATETIME NOT NULL,
+    value       DOUBLE NOT NULL,
+
+    PRIMARY KEY (user_id, date)
+);
+
+CREATE TABLE IF NOT EXISTS breathing_rate (
+    user_id     VARCHAR(150) NOT NULL,
+    date        DATETIME NOT NULL,
+    value       DOUBLE NOT NULL,
+
+    PRIMARY KEY (user_id, date)
+);
+
+CREATE TABLE IF NOT EXISTS skin_temp (
+    user_id             VARCHAR(150) NOT NULL,
+    date                DATETIME NOT NULL,
+    nightly_relative    DOUBLE NOT NULL,
+    log_type            VARCHAR(150) NOT NULL,
+
+    PRIMARY KEY (user_id, date)
+);
+
+COMMIT;
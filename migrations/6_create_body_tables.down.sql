@@ -0,0 +1,2 @@
+DROP TABLE body_fat_log;
+DROP TABLE weight_log;
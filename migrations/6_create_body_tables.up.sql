@@ -0,0 +1,24 @@
+BEGIN;
+
+CREATE TABLE IF NOT EXISTS weight_log (
+    log_id      BIGINT PRIMARY KEY,
+    user_id     VARCHAR(150) NOT NULL,
+    date        DATETIME NOT NULL,
+    weight      DOUBLE NOT NULL,
+    bmi         DOUBLE NOT NULL,
+    fat         DOUBLE NOT NULL,
+    source      VARCHAR(150) NOT NULL
+);
+
+CREATE TABLE IF NOT EXISTS body_fat_log (
+    log_id      BIGINT PRIMARY KEY,
+    user_id     VARCHAR(150) NOT NULL,
+    date        DATETIME NOT NULL,
+    fat         DOUBLE NOT NULL,
+    source      VARCHAR(150) NOT NULL
+);
+
+CREATE INDEX weight_log_user_date ON weight_log (user_id, date);
+CREATE INDEX body_fat_log_user_date ON body_fat_log (user_id, date);
+
+COMMIT;
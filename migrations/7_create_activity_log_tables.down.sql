@@ -0,0 +1,2 @@
+DROP TABLE activity_trackpoint;
+DROP TABLE activity_log;
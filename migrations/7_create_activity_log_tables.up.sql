@@ -0,0 +1,34 @@
+BEGIN;
+
+CREATE TABLE IF NOT EXISTS activity_log (
+    log_id              BIGINT PRIMARY KEY,
+    user_id             VARCHAR(150) NOT NULL,
+    name                VARCHAR(150) NOT NULL,
+    activity_type_id    INT NOT NULL,
+    log_type            VARCHAR(150) NOT NULL,
+    start_time          DATETIME NOT NULL,
+    duration            INT NOT NULL,
+    active_duration     INT NOT NULL,
+    calories            INT NOT NULL,
+    distance            DOUBLE NOT NULL,
+    distance_unit       VARCHAR(150) NOT NULL,
+    steps               INT NOT NULL,
+    average_heart_rate  INT NOT NULL,
+    elevation_gain      DOUBLE NOT NULL
+);
+
+CREATE TABLE IF NOT EXISTS activity_trackpoint (
+    log_id      BIGINT NOT NULL,
+    date        DATETIME NOT NULL,
+    latitude    DOUBLE,
+    longitude   DOUBLE,
+    altitude    DOUBLE,
+    distance    DOUBLE,
+    heart_rate  INT,
+
+    PRIMARY KEY (log_id, date)
+);
+
+CREATE INDEX activity_log_user_start_time ON activity_log (user_id, start_time);
+
+COMMIT;
@@ -0,0 +1,16 @@
+BEGIN;
+
+CREATE TABLE IF NOT EXISTS device (
+    id              VARCHAR(150) NOT NULL,
+    user_id         VARCHAR(150) NOT NULL,
+    type            VARCHAR(150) NOT NULL,
+    device_version  VARCHAR(150) NOT NULL,
+    battery         VARCHAR(150) NOT NULL,
+    battery_level   INT NOT NULL,
+    last_sync_time  DATETIME NOT NULL,
+    mac             VARCHAR(150) NOT NULL,
+
+    PRIMARY KEY (user_id, id)
+);
+
+COMMIT;
@@ -0,0 +1,2 @@
+DROP TABLE azm_intraday;
+DROP TABLE azm_daily;
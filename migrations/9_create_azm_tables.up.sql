@@ -0,0 +1,25 @@
+BEGIN;
+
+CREATE TABLE IF NOT EXISTS azm_daily (
+    user_id     VARCHAR(150) NOT NULL,
+    date        DATETIME NOT NULL,
+    total       INT NOT NULL,
+    fat_burn    INT NOT NULL,
+    cardio      INT NOT NULL,
+    peak        INT NOT NULL,
+
+    PRIMARY KEY (user_id, date)
+);
+
+CREATE TABLE IF NOT EXISTS azm_intraday (
+    user_id     VARCHAR(150) NOT NULL,
+    date        DATETIME NOT NULL,
+    total       INT NOT NULL,
+    fat_burn    INT NOT NULL,
+    cardio      INT NOT NULL,
+    peak        INT NOT NULL,
+
+    PRIMARY KEY (user_id, date)
+);
+
+COMMIT;
@@ -1,19 +1,39 @@
 package config
 
 import (
+	"fmt"
 	"io/ioutil"
+	"net/url"
+	"strings"
+	"time"
 
 	"gopkg.in/yaml.v2"
 )
 
+const defaultPublicURL = "http://localhost:3000/"
+
 type Config struct {
 	WebFrontend struct {
 		Listen     string
 		SessionKey string `yaml:"sessionKey"`
+		// PublicURL is the externally reachable url of the frontend and may include a path prefix
+		PublicURL string `yaml:"publicURL"`
+		// AdminToken has to be entered to remove users from the frontend which is disabled when it is not set
+		AdminToken string `yaml:"adminToken"`
 	} `yaml:"webFrontend"`
 	Fitbit struct {
-		ClientID     string `yaml:"clientId"`
-		ClientSecret string `yaml:"clientSecret"`
+		ClientID     string                `yaml:"clientId"`
+		ClientSecret string                `yaml:"clientSecret"`
+		Users        map[string]UserConfig `yaml:"users"`
+		// Intraday is the default for users without an override and is enabled when not set
+		Intraday *bool `yaml:"intraday"`
+		// RateLimitReserve is the number of hourly api calls per user kept for live refreshes and logins
+		RateLimitReserve *int `yaml:"rateLimitReserve"`
+		// HotWindowDays is the number of recent days, including today, fetched again on every run
+		HotWindowDays *int                `yaml:"hotWindowDays"`
+		Subscriptions SubscriptionsConfig `yaml:"subscriptions"`
+		// Workers is the number of users synced at the same time
+		Workers *int `yaml:"workers"`
 	}
 	Database struct {
 		Host     string
@@ -23,6 +43,24 @@ type Config struct {
 	}
 }
 
+// UserConfig holds per user overrides keyed by the fitbit user id
+type UserConfig struct {
+	// HeartRateDetailLevel is the intraday heart rate granularity, either 1sec or 1min. Days older than the hot
+	// window always use 1min as 1sec stores around 86k rows per day.
+	HeartRateDetailLevel string `yaml:"heartRateDetailLevel"`
+	// Intraday enables the per day intraday heart rate, activity, active zone minute, hrv and spo2 requests
+	Intraday *bool `yaml:"intraday"`
+}
+
+// SubscriptionsConfig enables fitbit to notify the exporter when new data is available
+type SubscriptionsConfig struct {
+	Enabled bool
+	// VerificationCode is the code shown for the subscriber in the fitbit app settings
+	VerificationCode string `yaml:"verificationCode"`
+	// SubscriberID is only needed when the app has more than one subscriber
+	SubscriberID string `yaml:"subscriberId"`
+}
+
 func LoadConfig(path string) (*Config, error) {
 	data, err := ioutil.ReadFile(path)
 	if err != nil {
@@ -34,5 +72,109 @@ func LoadConfig(path string) (*Config, error) {
 		return nil, err
 	}
 
+	if config.WebFrontend.PublicURL == "" {
+		config.WebFrontend.PublicURL = defaultPublicURL
+	}
+	publicURL, err := url.Parse(config.WebFrontend.PublicURL)
+	if err != nil {
+		return nil, fmt.Errorf("invalid public url: %s", err)
+	}
+	if publicURL.Scheme != "http" && publicURL.Scheme != "https" {
+		return nil, fmt.Errorf("invalid public url %q: scheme must be http or https", config.WebFrontend.PublicURL)
+	}
+	if !strings.HasSuffix(config.WebFrontend.PublicURL, "/") {
+		config.WebFrontend.PublicURL += "/"
+	}
+
+	if config.Fitbit.RateLimitReserve != nil && *config.Fitbit.RateLimitReserve < 0 {
+		return nil, fmt.Errorf("invalid rate limit reserve %d: must not be negative", *config.Fitbit.RateLimitReserve)
+	}
+
+	if config.Fitbit.HotWindowDays != nil && *config.Fitbit.HotWindowDays < 1 {
+		return nil, fmt.Errorf("invalid hot window %d: must be at least 1 day", *config.Fitbit.HotWindowDays)
+	}
+
+	if config.Fitbit.Workers != nil && *config.Fitbit.Workers < 1 {
+		return nil, fmt.Errorf("invalid workers %d: must be at least 1", *config.Fitbit.Workers)
+	}
+
+	if config.Fitbit.Subscriptions.Enabled && config.Fitbit.Subscriptions.VerificationCode == "" {
+		return nil, fmt.Errorf("subscriptions require a verification code")
+	}
+
+	for user, userConfig := range config.Fitbit.Users {
+		switch userConfig.HeartRateDetailLevel {
+		case "", "1sec", "1min":
+		default:
+			return nil, fmt.Errorf("invalid heart rate detail level %q for user %s", userConfig.HeartRateDetailLevel, user)
+		}
+	}
+
 	return config, nil
 }
+
+// HeartRateDetailLevel returns the intraday heart rate detail level for the user defaulting to 1min
+func (c *Config) HeartRateDetailLevel(user string) string {
+	if userConfig, ok := c.Fitbit.Users[user]; ok && userConfig.HeartRateDetailLevel != "" {
+		return userConfig.HeartRateDetailLevel
+	}
+	return "1min"
+}
+
+// IntradayEnabled returns if intraday data should be collected for the user
+func (c *Config) IntradayEnabled(user string) bool {
+	if userConfig, ok := c.Fitbit.Users[user]; ok && userConfig.Intraday != nil {
+		return *userConfig.Intraday
+	}
+	if c.Fitbit.Intraday != nil {
+		return *c.Fitbit.Intraday
+	}
+	return true
+}
+
+// RateLimitReserve returns the number of hourly api calls backfills leave unused defaulting to 10
+func (c *Config) RateLimitReserve() int {
+	if c.Fitbit.RateLimitReserve != nil {
+		return *c.Fitbit.RateLimitReserve
+	}
+	return 10
+}
+
+// HotWindowDays returns the number of recent days re-synced on every run defaulting to today and yesterday
+func (c *Config) HotWindowDays() int {
+	if c.Fitbit.HotWindowDays != nil {
+		return *c.Fitbit.HotWindowDays
+	}
+	return 2
+}
+
+// Workers returns the number of sync workers defaulting to 4
+func (c *Config) Workers() int {
+	if c.Fitbit.Workers != nil {
+		return *c.Fitbit.Workers
+	}
+	return 4
+}
+
+// PollInterval returns how often every user is synced. With subscriptions enabled fitbit tells when
+// recent data changes so polling is only needed for the history and any missed notifications.
+func (c *Config) PollInterval() time.Duration {
+	if c.Fitbit.Subscriptions.Enabled {
+		return 24 * time.Hour
+	}
+	return time.Hour
+}
+
+// PublicURL returns the externally reachable url of the frontend for the given path relative to it
+func (c *Config) PublicURL(path string) string {
+	return c.WebFrontend.PublicURL + strings.TrimPrefix(path, "/")
+}
+
+// PublicPath returns the path prefix of the public url with a trailing slash such as /fitbit/
+func (c *Config) PublicPath() string {
+	publicURL, err := url.Parse(c.WebFrontend.PublicURL)
+	if err != nil || publicURL.Path == "" {
+		return "/"
+	}
+	return publicURL.Path
+}
@@ -2,7 +2,6 @@ package exporter
 
 import (
 	"context"
-	"database/sql"
 	"fmt"
 	"log"
 	"net/http"
@@ -11,91 +10,588 @@ import (
 	"github.com/bah2830/fitbit-exporter/pkg/fitbit"
 )
 
-const fitbitCallsPerHour = 250
+// syncRecent will refresh the current day and catch up on everything since the last sync for the user
+func (e *Exporter) syncRecent(user *fitbit.User) error {
+	detailLevel := fitbit.HeartRateDetailLevel(e.cfg.HeartRateDetailLevel(user.ID))
+	intraday := e.cfg.IntradayEnabled(user.ID)
 
-func (e *Exporter) backfill() error {
-	log.Print("Starting fitbit data backfill...")
-	startTime := time.Now()
+	if intraday {
+		if err := e.refreshHeartData(user, detailLevel); err != nil {
+			return err
+		}
+	}
 
-	for _, user := range e.client.Users {
-		startDate := time.Now()
+	if err := e.refreshDevices(user); err != nil {
+		return err
+	}
 
-		// Get the earliest date from the database
-		var date string
-		if err := e.db.GetDB().QueryRow("select date from heart_rest where user_id = ? order by date ASC", user.ID).Scan(&date); err != nil {
-			if err != sql.ErrNoRows {
-				return err
+	if err := e.refreshLifetime(user); err != nil {
+		return err
+	}
+
+	for _, list := range e.listSyncs(user) {
+		if err := e.syncListRecent(user, list); err != nil {
+			return err
+		}
+	}
+
+	for _, newSync := range e.rangeSyncs(user, true) {
+		r, err := newSync()
+		if err != nil {
+			return err
+		}
+		if err := r.run(); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// syncHistory returns the steps that continue back through the history of the user. Each call syncs a single range
+// or list so other users get a turn in between and it reports true once the whole history has been synced.
+func (e *Exporter) syncHistory(user *fitbit.User) func() (bool, error) {
+	var stages []func() (bool, error)
+	for _, newSync := range e.rangeSyncs(user, false) {
+		var r *rangeSync
+		newSync := newSync
+		stages = append(stages, func() (bool, error) {
+			if r == nil {
+				var err error
+				if r, err = newSync(); err != nil {
+					return false, err
+				}
 			}
+			return r.step()
+		})
+	}
+	for _, list := range e.listSyncs(user) {
+		list := list
+		stages = append(stages, func() (bool, error) {
+			return true, e.syncListHistory(user, list)
+		})
+	}
+
+	startTime := time.Now()
+	return func() (bool, error) {
+		done, err := stages[0]()
+		if err != nil {
+			return false, err
 		}
-		if date != "" {
-			earliestDate, err := time.Parse(dateTimeFormat, date)
+		if done {
+			stages = stages[1:]
+		}
+		if len(stages) == 0 {
+			log.Printf("History sync completed for %s... completed in %s", user.FullName, time.Since(startTime).String())
+			return true, nil
+		}
+		return false, nil
+	}
+}
+
+// rangeSyncs returns the constructors for every data type synced a range of days at a time. They are only called
+// when each sync starts as the daily sync stops at the first day with heart rate data which is only known once the
+// heart rate summaries have been synced.
+func (e *Exporter) rangeSyncs(user *fitbit.User, recent bool) []func() (*rangeSync, error) {
+	return []func() (*rangeSync, error){
+		func() (*rangeSync, error) {
+			floor, err := memberSince(user)
 			if err != nil {
-				return err
+				return nil, err
+			}
+			// A full range without a resting heart rate is the end of the available history
+			return e.newRangeSync(user, syncHeartSummary, fitbit.HeartRateMaxRange, 1, floor, recent, e.fetchHeartSummaries(user))
+		},
+		func() (*rangeSync, error) {
+			floor, err := memberSince(user)
+			if err != nil {
+				return nil, err
+			}
+			// After 2 ranges of no data consider the history complete
+			return e.newRangeSync(user, syncCardioScore, fitbit.CardioScoreMaxRange, 2, floor, recent, e.fetchCardioScores(user))
+		},
+		func() (*rangeSync, error) {
+			floor, err := memberSince(user)
+			if err != nil {
+				return nil, err
 			}
+			// Logs such as weight can be entered without a tracker so go all the way back to when the user joined
+			return e.newRangeSync(user, syncDailySummary, fitbit.VitalsMaxRange, 0, floor, recent, e.fetchDailySummaries(user))
+		},
+		func() (*rangeSync, error) {
+			detailLevel := fitbit.HeartRateDetailLevel(e.cfg.HeartRateDetailLevel(user.ID))
+			intraday := e.cfg.IntradayEnabled(user.ID)
+			today := truncateDay(time.Now())
+			hotStart := today.AddDate(0, 0, -(e.cfg.HotWindowDays() - 1))
+			fetch := func(ctx context.Context, date, _ time.Time) (bool, error) {
+				// The recent sync has already caught up on the heart rate for today from the last saved sample
+				heart := !(recent && date.Equal(today))
 
-			if earliestDate.Before(startDate) {
-				startDate = earliestDate
+				// A day of 1sec samples is around 86k rows so it is only kept for the hot window
+				level := detailLevel
+				if date.Before(hotStart) {
+					level = fitbit.HeartRateDetailLevel1Min
+				}
+				return true, e.backfillDay(ctx, user, date, intraday, heart, level)
 			}
-		}
 
-		log.Printf("Starting backfill for %s from %s", user.FullName, startDate.Format(dateFormat))
+			if recent {
+				// The recent sync can run before the heart rate history is known so it only covers the hot window
+				floor, err := memberSince(user)
+				if err != nil {
+					return nil, err
+				}
+				return e.newRangeSync(user, syncDaily, 24*time.Hour, 0, floor, recent, fetch)
+			}
 
-		// After 2 days of no data consider the backfill complete
-		var daysWithoutData int
+			state, err := e.client.GetSyncState(user.ID, syncHeartSummary)
+			if err != nil {
+				return nil, err
+			}
+			if !state.Complete {
+				return nil, fmt.Errorf("heart rate history for %s not synced yet", user.ID)
+			}
 
-		// Loop through every day from the earliest date up until no more data is returned
-		// Because of rate limits on the fitbit api we have to check for the too many calls response
-		// When too many calls is hit we wait until the next hour mark for it reset and continue
-		for {
-			ctx, cancel := context.WithTimeout(context.Background(), 3*time.Hour)
-			defer cancel()
-			d, err := e.getHeartData(ctx, user.ID, startDate)
+			oldest, _, err := e.client.GetRestingRange(user.ID)
 			if err != nil {
-				return err
+				return nil, err
+			}
+			if oldest == nil {
+				log.Printf("No heart rate data found for %s, skipping daily sync", user.FullName)
+				return &rangeSync{done: true}, nil
 			}
 
-			// If no intraday data found then we've hit the end of data available
-			if (d.IntraDay == nil || len(d.IntraDay.Data) == 0) && len(d.OverviewByDay) == 0 {
-				daysWithoutData++
-				if daysWithoutData >= 2 {
-					break
-				}
+			// Go through every day back to the first day with heart rate data
+			return e.newRangeSync(user, syncDaily, 24*time.Hour, 0, *oldest, recent, fetch)
+		},
+	}
+}
 
-			}
+// refreshHeartData will get the intraday heart rate data for the current day starting
+// from the last saved sample rather than downloading the whole day again
+func (e *Exporter) refreshHeartData(user *fitbit.User, detailLevel fitbit.HeartRateDetailLevel) error {
+	ctx, cancel := context.WithTimeout(fitbit.WithPriority(context.Background(), fitbit.PriorityLive), 3*time.Hour)
+	defer cancel()
 
-			if err := user.SaveHeartRateData(e.db.GetDB(), d); err != nil {
-				return err
+	now := time.Now()
+	opts := fitbit.HeartRateOptions{
+		StartDate:   &now,
+		EndDate:     &now,
+		DetailLevel: fitbit.GetHeartRateDetailLevel(detailLevel),
+	}
+
+	latest, err := e.client.GetLatestHeartRate(user.ID)
+	if err != nil {
+		return err
+	}
+	if latest != nil {
+		latestTime, err := time.ParseInLocation(dateTimeFormat, latest.Time, time.Local)
+		if err != nil {
+			return err
+		}
+		if latestTime.Format(dateFormat) == now.Format(dateFormat) {
+			opts.StartTime = &latestTime
+		}
+	}
+
+	var d *fitbit.HeartRateData
+	if err := e.withRetry(ctx, user.ID, now, func() (err error) {
+		d, err = e.client.GetHeartData(user.ID, opts)
+		return err
+	}); err != nil {
+		return err
+	}
+
+	return user.SaveHeartRateData(e.db.GetDB(), d)
+}
+
+// fetchHeartSummaries will save the daily resting heart rate and zones for a range of up to a year
+func (e *Exporter) fetchHeartSummaries(user *fitbit.User) rangeFetcher {
+	return func(ctx context.Context, startDate, endDate time.Time) (bool, error) {
+		var d *fitbit.HeartRateData
+		if err := e.withRetry(ctx, user.ID, startDate, func() (err error) {
+			d, err = e.client.GetHeartData(user.ID, fitbit.HeartRateOptions{
+				StartDate: &startDate,
+				EndDate:   &endDate,
+			})
+			return err
+		}); err != nil {
+			return false, err
+		}
+		if err := user.SaveHeartRateData(e.db.GetDB(), d); err != nil {
+			return false, err
+		}
+
+		for _, day := range d.OverviewByDay {
+			if day.Value.RestingHeartRate != 0 {
+				return true, nil
 			}
+		}
+		return false, nil
+	}
+}
 
-			// Go back one day
-			startDate = startDate.Add(-24 * time.Hour)
+// fetchDailySummaries will save the activity and active zone minute totals, sleep logs, daily vitals and body logs for a
+// range of days. The vitals endpoints have the shortest limit so it sets the range for all of them.
+func (e *Exporter) fetchDailySummaries(user *fitbit.User) rangeFetcher {
+	return func(ctx context.Context, startDate, endDate time.Time) (bool, error) {
+		if err := e.syncActivitySummaries(ctx, user, startDate, endDate); err != nil {
+			return false, err
 		}
 
-		log.Printf(
-			"Backfill completed for %s at %s... completed in %s",
-			user.FullName,
-			startDate.Format(dateFormat),
-			time.Since(startTime).String(),
-		)
+		if err := e.syncSleep(ctx, user, startDate, endDate); err != nil {
+			return false, err
+		}
+
+		return true, e.backfillBody(ctx, user, startDate, endDate)
 	}
+}
 
-	log.Printf("Backfill completed... completed in %s", time.Since(startTime).String())
-	return nil
+// backfillDay will get and save the data only available a single day at a time for the given date. Intraday data is
+// only fetched when enabled for the user and heart can skip the intraday heart rate when it is already up to date.
+func (e *Exporter) backfillDay(ctx context.Context, user *fitbit.User, date time.Time, intraday, heart bool, detailLevel fitbit.HeartRateDetailLevel) error {
+	if intraday {
+		if err := e.syncActivities(ctx, user, date, heart, detailLevel); err != nil {
+			return err
+		}
+
+		if err := e.syncSleepIntraday(ctx, user, date); err != nil {
+			return err
+		}
+	}
+
+	// Food and water logs have no range endpoint
+	return e.backfillNutrition(ctx, user, date)
+}
+
+// syncActivities will get and save the intraday heart rate, activity and active zone minutes for the given date.
+// The heart rate is skipped unless heart is set.
+func (e *Exporter) syncActivities(ctx context.Context, user *fitbit.User, date time.Time, heart bool, detailLevel fitbit.HeartRateDetailLevel) error {
+	if heart {
+		d, err := e.getHeartData(ctx, user.ID, date, detailLevel)
+		if err != nil {
+			return err
+		}
+		if err := user.SaveHeartRateData(e.db.GetDB(), d); err != nil {
+			return err
+		}
+	}
+
+	for _, resource := range fitbit.ActivityResources {
+		activity, err := e.getActivityData(ctx, user.ID, resource, date)
+		if err != nil {
+			return err
+		}
+		if err := user.SaveActivityData(e.db.GetDB(), activity); err != nil {
+			return err
+		}
+	}
+
+	azm, err := e.getAZMData(ctx, user.ID, date, date, fitbit.GetActivityDetailLevel(fitbit.ActivityDetailLevel1Min))
+	if err != nil {
+		return err
+	}
+	return user.SaveAZMData(e.db.GetDB(), azm)
+}
+
+// syncActivitySummaries will get and save the activity and active zone minute totals for every day in the range
+func (e *Exporter) syncActivitySummaries(ctx context.Context, user *fitbit.User, startDate, endDate time.Time) error {
+	for _, resource := range fitbit.ActivityResources {
+		var activity *fitbit.ActivityData
+		if err := e.withRetry(ctx, user.ID, startDate, func() (err error) {
+			activity, err = e.client.GetActivityData(user.ID, fitbit.ActivityOptions{
+				Resource:  resource,
+				StartDate: &startDate,
+				EndDate:   &endDate,
+			})
+			return err
+		}); err != nil {
+			return err
+		}
+		if err := user.SaveActivityData(e.db.GetDB(), activity); err != nil {
+			return err
+		}
+	}
+
+	azm, err := e.getAZMData(ctx, user.ID, startDate, endDate, nil)
+	if err != nil {
+		return err
+	}
+	return user.SaveAZMData(e.db.GetDB(), azm)
+}
+
+// syncSleep will get and save the sleep logs for the range along with the daily hrv and vitals measured during sleep
+func (e *Exporter) syncSleep(ctx context.Context, user *fitbit.User, startDate, endDate time.Time) error {
+	sleep, err := e.getSleepData(ctx, user.ID, startDate, endDate)
+	if err != nil {
+		return err
+	}
+	if err := user.SaveSleepData(e.db.GetDB(), sleep); err != nil {
+		return err
+	}
+
+	hrv, err := e.getHRVData(ctx, user.ID, startDate, endDate, false)
+	if err != nil {
+		return err
+	}
+	if err := user.SaveHRVData(e.db.GetDB(), hrv); err != nil {
+		return err
+	}
+
+	return e.backfillVitals(ctx, user, startDate, endDate)
+}
+
+// syncSleepIntraday will get and save the hrv and spo2 samples taken during the sleep ending on the given date
+func (e *Exporter) syncSleepIntraday(ctx context.Context, user *fitbit.User, date time.Time) error {
+	hrv, err := e.getHRVData(ctx, user.ID, date, date, true)
+	if err != nil {
+		return err
+	}
+	if err := user.SaveHRVData(e.db.GetDB(), hrv); err != nil {
+		return err
+	}
+
+	var spo2IntraDay *fitbit.SpO2IntraDay
+	if err := e.withRetry(ctx, user.ID, date, func() (err error) {
+		spo2IntraDay, err = e.client.GetSpO2IntraDay(user.ID, date)
+		return err
+	}); err != nil {
+		return err
+	}
+	return user.SaveSpO2IntraDay(e.db.GetDB(), spo2IntraDay)
 }
 
 // getHeartData will attempt to get the heart rate data from the api
 // repeatidly until it no longer has a rate limit error or the timeout occurs
-func (e *Exporter) getHeartData(ctx context.Context, user string, date time.Time) (*fitbit.HeartRateData, error) {
+func (e *Exporter) getHeartData(ctx context.Context, user string, date time.Time, detailLevel fitbit.HeartRateDetailLevel) (*fitbit.HeartRateData, error) {
+	var d *fitbit.HeartRateData
+	err := e.withRetry(ctx, user, date, func() (err error) {
+		d, err = e.client.GetHeartData(user, fitbit.HeartRateOptions{
+			StartDate:   &date,
+			EndDate:     &date,
+			DetailLevel: fitbit.GetHeartRateDetailLevel(detailLevel),
+		})
+		return err
+	})
+	return d, err
+}
+
+// getSleepData will attempt to get the sleep logs ending between both dates
+func (e *Exporter) getSleepData(ctx context.Context, user string, startDate, endDate time.Time) (*fitbit.SleepData, error) {
+	var d *fitbit.SleepData
+	err := e.withRetry(ctx, user, startDate, func() (err error) {
+		d, err = e.client.GetSleepLogs(user, fitbit.SleepOptions{StartDate: &startDate, EndDate: &endDate})
+		return err
+	})
+	return d, err
+}
+
+// getActivityData will attempt to get the intraday activity data for a single resource on the given date
+func (e *Exporter) getActivityData(ctx context.Context, user string, resource fitbit.ActivityResource, date time.Time) (*fitbit.ActivityData, error) {
+	var d *fitbit.ActivityData
+	err := e.withRetry(ctx, user, date, func() (err error) {
+		d, err = e.client.GetActivityData(user, fitbit.ActivityOptions{
+			Resource:    resource,
+			StartDate:   &date,
+			DetailLevel: fitbit.GetActivityDetailLevel(fitbit.ActivityDetailLevel1Min),
+		})
+		return err
+	})
+	return d, err
+}
+
+// getHRVData will attempt to get either the daily hrv between both dates or the intraday hrv for a single date
+func (e *Exporter) getHRVData(ctx context.Context, user string, startDate, endDate time.Time, intraday bool) (*fitbit.HRVData, error) {
+	opts := fitbit.HRVOptions{StartDate: &startDate, Intraday: intraday}
+	if !intraday {
+		opts.EndDate = &endDate
+	}
+
+	var d *fitbit.HRVData
+	err := e.withRetry(ctx, user, startDate, func() (err error) {
+		d, err = e.client.GetHRV(user, opts)
+		return err
+	})
+	return d, err
+}
+
+// getAZMData will attempt to get the daily active zone minutes between both dates or the intraday ones for the
+// start date when a detail level is given
+func (e *Exporter) getAZMData(ctx context.Context, user string, startDate, endDate time.Time, detailLevel *fitbit.ActivityDetailLevel) (*fitbit.AZMData, error) {
+	opts := fitbit.AZMOptions{StartDate: &startDate, DetailLevel: detailLevel}
+	if detailLevel == nil {
+		opts.EndDate = &endDate
+	}
+
+	var d *fitbit.AZMData
+	err := e.withRetry(ctx, user, startDate, func() (err error) {
+		d, err = e.client.GetActiveZoneMinutes(user, opts)
+		return err
+	})
+	return d, err
+}
+
+// backfillVitals will get and save the nightly spo2, breathing rate and skin temperature between both dates
+func (e *Exporter) backfillVitals(ctx context.Context, user *fitbit.User, startDate, endDate time.Time) error {
+	opts := fitbit.VitalsOptions{StartDate: &startDate, EndDate: &endDate}
+
+	var spo2 []fitbit.SpO2OverView
+	if err := e.withRetry(ctx, user.ID, startDate, func() (err error) {
+		spo2, err = e.client.GetSpO2(user.ID, opts)
+		return err
+	}); err != nil {
+		return err
+	}
+	if err := user.SaveSpO2Data(e.db.GetDB(), spo2); err != nil {
+		return err
+	}
+
+	var breathingRate *fitbit.BreathingRateData
+	if err := e.withRetry(ctx, user.ID, startDate, func() (err error) {
+		breathingRate, err = e.client.GetBreathingRate(user.ID, opts)
+		return err
+	}); err != nil {
+		return err
+	}
+	if err := user.SaveBreathingRateData(e.db.GetDB(), breathingRate); err != nil {
+		return err
+	}
+
+	var skinTemp *fitbit.SkinTempData
+	if err := e.withRetry(ctx, user.ID, startDate, func() (err error) {
+		skinTemp, err = e.client.GetSkinTemp(user.ID, opts)
+		return err
+	}); err != nil {
+		return err
+	}
+	return user.SaveSkinTempData(e.db.GetDB(), skinTemp)
+}
+
+// backfillBody will get and save any weight and body fat logs between both dates
+func (e *Exporter) backfillBody(ctx context.Context, user *fitbit.User, startDate, endDate time.Time) error {
+	opts := fitbit.BodyOptions{StartDate: &startDate, EndDate: &endDate}
+
+	var weight *fitbit.WeightData
+	if err := e.withRetry(ctx, user.ID, startDate, func() (err error) {
+		weight, err = e.client.GetWeightLogs(user.ID, opts)
+		return err
+	}); err != nil {
+		return err
+	}
+	if err := user.SaveWeightData(e.db.GetDB(), weight); err != nil {
+		return err
+	}
+
+	var fat *fitbit.BodyFatData
+	if err := e.withRetry(ctx, user.ID, startDate, func() (err error) {
+		fat, err = e.client.GetBodyFatLogs(user.ID, opts)
+		return err
+	}); err != nil {
+		return err
+	}
+	return user.SaveBodyFatData(e.db.GetDB(), fat)
+}
+
+// refreshDevices will replace the saved devices with the currently paired ones
+func (e *Exporter) refreshDevices(user *fitbit.User) error {
+	ctx, cancel := context.WithTimeout(fitbit.WithPriority(context.Background(), fitbit.PriorityLive), 3*time.Hour)
+	defer cancel()
+
+	var devices []fitbit.Device
+	if err := e.withRetry(ctx, user.ID, time.Now(), func() (err error) {
+		devices, err = e.client.GetDevices(user.ID)
+		return err
+	}); err != nil {
+		return err
+	}
+
+	return user.SaveDevices(e.db.GetDB(), devices)
+}
+
+// refreshLifetime will save a snapshot of the lifetime totals and any newly earned badges
+func (e *Exporter) refreshLifetime(user *fitbit.User) error {
+	ctx, cancel := context.WithTimeout(fitbit.WithPriority(context.Background(), fitbit.PriorityLive), 3*time.Hour)
+	defer cancel()
+
+	var stats *fitbit.LifetimeStatsData
+	if err := e.withRetry(ctx, user.ID, time.Now(), func() (err error) {
+		stats, err = e.client.GetLifetimeStats(user.ID)
+		return err
+	}); err != nil {
+		return err
+	}
+	if err := user.SaveLifetimeStats(e.db.GetDB(), stats); err != nil {
+		return err
+	}
+
+	var badges *fitbit.BadgeData
+	if err := e.withRetry(ctx, user.ID, time.Now(), func() (err error) {
+		badges, err = e.client.GetBadges(user.ID)
+		return err
+	}); err != nil {
+		return err
+	}
+	return user.SaveBadges(e.db.GetDB(), badges)
+}
+
+// backfillNutrition will get and save the food and water logs for the given date
+func (e *Exporter) backfillNutrition(ctx context.Context, user *fitbit.User, date time.Time) error {
+	var food *fitbit.FoodLogData
+	if err := e.withRetry(ctx, user.ID, date, func() (err error) {
+		food, err = e.client.GetFoodLogs(user.ID, date)
+		return err
+	}); err != nil {
+		return err
+	}
+
+	var water *fitbit.WaterLogData
+	if err := e.withRetry(ctx, user.ID, date, func() (err error) {
+		water, err = e.client.GetWaterLogs(user.ID, date)
+		return err
+	}); err != nil {
+		return err
+	}
+
+	return user.SaveNutritionData(e.db.GetDB(), date, food, water)
+}
+
+// fetchCardioScores will save the cardio scores for a range of up to 30 days
+func (e *Exporter) fetchCardioScores(user *fitbit.User) rangeFetcher {
+	return func(ctx context.Context, startDate, endDate time.Time) (bool, error) {
+		var data *fitbit.CardioScoreData
+		if err := e.withRetry(ctx, user.ID, startDate, func() (err error) {
+			data, err = e.client.GetCardioScore(user.ID, fitbit.CardioScoreOptions{
+				StartDate: &startDate,
+				EndDate:   &endDate,
+			})
+			return err
+		}); err != nil {
+			return false, err
+		}
+		if err := user.SaveCardioScoreData(e.db.GetDB(), data); err != nil {
+			return false, err
+		}
+
+		return len(data.OverviewByDay) > 0, nil
+	}
+}
+
+// withRetry will call fn repeatidly until it no longer has a rate limit error or the timeout occurs
+func (e *Exporter) withRetry(ctx context.Context, user string, date time.Time, fn func() error) error {
 	for {
 		select {
 		case <-ctx.Done():
-			return nil, fmt.Errorf("timeout waiting to get api data")
+			return fmt.Errorf("timeout waiting to get api data")
 		default:
-			d, err := e.client.GetHeartData(user, fitbit.HeartRateOptions{
-				StartDate:   &date,
-				EndDate:     &date,
-				DetailLevel: fitbit.GetHeartRateDetailLevel(fitbit.HeartRateDetailLevel1Min),
-			})
+			// Hold off before the limit is hit rather than relying on the too many requests response
+			if err := e.client.WaitForBudget(ctx, user); err != nil {
+				if err == ctx.Err() {
+					return fmt.Errorf("timeout waiting to get api data")
+				}
+				return err
+			}
+
+			err := fn()
 			if err != nil {
 				if requestErr, ok := err.(*fitbit.RequestError); ok {
 					// If this is a rate limit hit then just sleep until the hour is up and try again
@@ -112,10 +608,10 @@ func (e *Exporter) getHeartData(ctx context.Context, user string, date time.Time
 					}
 
 				}
-				return nil, err
+				return err
 			}
 
-			return d, nil
+			return nil
 		}
 	}
 }
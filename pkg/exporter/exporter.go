@@ -1,11 +1,14 @@
 package exporter
 
 import (
+	"log"
+	"sync"
 	"time"
 
 	"github.com/bah2830/fitbit-exporter/pkg/config"
 	"github.com/bah2830/fitbit-exporter/pkg/database"
 	"github.com/bah2830/fitbit-exporter/pkg/fitbit"
+	"github.com/prometheus/client_golang/prometheus"
 )
 
 const (
@@ -14,47 +17,122 @@ const (
 )
 
 type Exporter struct {
-	db              *database.Database
-	client          *fitbit.Client
-	cfg             *config.Config
-	BackfillRunning bool
-	BackfillLastRun time.Time
+	db     *database.Database
+	client *fitbit.Client
+	cfg    *config.Config
+	queue  *scheduler
+
+	lastRun   time.Time
+	lastRunMu sync.Mutex
+
+	// pending holds the notifications waiting out the notification delay
+	pending   map[string]struct{}
+	pendingMu sync.Mutex
 }
 
 func New(cfg *config.Config, client *fitbit.Client, db *database.Database) *Exporter {
-	return &Exporter{
-		cfg:    cfg,
-		client: client,
-		db:     db,
+	prometheus.MustRegister(&userCollector{client: client})
+
+	e := &Exporter{
+		cfg:     cfg,
+		client:  client,
+		db:      db,
+		pending: make(map[string]struct{}),
 	}
+	e.queue = newScheduler(e.historyBudgetWait, e.syncFinished)
+	return e
 }
 
 func (e *Exporter) Start() error {
-	if err := e.runBackfiller(); err != nil {
-		return err
+	if e.cfg.Fitbit.Subscriptions.Enabled {
+		log.Printf("Receiving fitbit notifications at %s", e.cfg.PublicURL("webhook/fitbit"))
+		e.subscribeUsers()
 	}
 
-	// Run a backfill every hour to keep the most up to date data
-	for range time.After(1 * time.Hour) {
-		if err := e.runBackfiller(); err != nil {
-			return err
-		}
+	for i := 0; i < e.cfg.Workers(); i++ {
+		go e.worker()
+	}
+
+	if e.cfg.Fitbit.Subscriptions.Enabled {
+		go e.refreshDevicesPeriodically()
+	}
+
+	e.syncAll()
+
+	// Sync periodically to keep the most up to date data and continue with the history
+	ticker := time.NewTicker(e.cfg.PollInterval())
+	defer ticker.Stop()
+	for range ticker.C {
+		e.syncAll()
 	}
 
 	return nil
 }
 
 func (e *Exporter) Stop() error {
+	e.queue.close()
 	return e.client.Close()
 }
 
-func (e *Exporter) runBackfiller() error {
-	defer func() {
-		e.BackfillRunning = false
-	}()
+// SyncUser queues a refresh of the recent data for the user followed by the rest of their history
+func (e *Exporter) SyncUser(user *fitbit.User) {
+	e.queue.add(&job{
+		key:      "recent",
+		user:     user.ID,
+		backfill: true,
+		run: func() (bool, error) {
+			return false, e.syncRecent(user)
+		},
+	})
+	e.queue.add(&job{
+		key:      "history",
+		user:     user.ID,
+		history:  true,
+		backfill: true,
+		run:      e.syncHistory(user),
+	})
+}
+
+// BackfillRunning reports if any backfill is queued or running
+func (e *Exporter) BackfillRunning() bool {
+	return e.queue.busy()
+}
+
+// BackfillLastRun returns when every user was last queued for a sync
+func (e *Exporter) BackfillLastRun() time.Time {
+	e.lastRunMu.Lock()
+	defer e.lastRunMu.Unlock()
+	return e.lastRun
+}
 
-	e.BackfillRunning = true
-	e.BackfillLastRun = time.Now()
+func (e *Exporter) syncAll() {
+	log.Print("Starting fitbit data sync...")
 
-	return e.backfill()
+	e.lastRunMu.Lock()
+	e.lastRun = time.Now()
+	e.lastRunMu.Unlock()
+	backfillRunning.Set(1)
+	backfillLastRun.Set(float64(e.BackfillLastRun().Unix()))
+
+	for _, user := range e.client.GetUsers() {
+		e.SyncUser(user)
+	}
+}
+
+// syncFinished is called by the scheduler once every queued backfill job is done
+func (e *Exporter) syncFinished() {
+	log.Printf("Sync completed... completed in %s", time.Since(e.BackfillLastRun()).String())
+	backfillRunning.Set(0)
+	backfillDuration.Set(time.Since(e.BackfillLastRun()).Seconds())
+}
+
+// worker runs jobs until the scheduler is closed
+func (e *Exporter) worker() {
+	for j := e.queue.take(); j != nil; j = e.queue.take() {
+		more, err := j.run()
+		if err != nil {
+			log.Printf("Error running %s sync for %s: %s", j.key, j.user, err)
+		}
+		e.queue.done(j, more)
+	}
 }
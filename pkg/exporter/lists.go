@@ -0,0 +1,243 @@
+package exporter
+
+import (
+	"context"
+	"time"
+
+	"github.com/bah2830/fitbit-exporter/pkg/fitbit"
+)
+
+// listPager gets and saves a single page of a paginated list endpoint returning the link to the next page
+type listPager struct {
+	first func(ctx context.Context, opts fitbit.ListOptions) (string, error)
+	next  func(ctx context.Context, link string) (string, error)
+}
+
+// listSync describes a paginated list endpoint and how far back its entries have been saved
+type listSync struct {
+	dataType   string
+	savedRange func(user string) (*time.Time, *time.Time, error)
+	pager      listPager
+}
+
+// listSyncs returns every list endpoint synced for the user
+func (e *Exporter) listSyncs(user *fitbit.User) []listSync {
+	return []listSync{
+		e.activityLogSync(user),
+		e.ecgReadingSync(user),
+		e.irnAlertSync(user),
+	}
+}
+
+// syncListRecent will save any entries newer than the newest one saved
+func (e *Exporter) syncListRecent(user *fitbit.User, list listSync) error {
+	ctx, cancel := context.WithTimeout(fitbit.WithPriority(context.Background(), fitbit.PriorityLive), 3*time.Hour)
+	defer cancel()
+
+	state, err := e.client.GetSyncState(user.ID, list.dataType)
+	if err != nil {
+		return err
+	}
+	_, newest, err := list.savedRange(user.ID)
+	if err != nil {
+		return err
+	}
+
+	// Without any saved entries the last sync is the point to look for new ones from.
+	// Until the history is complete new entries are also found while paging back from the start.
+	if newest == nil && state.Complete {
+		newest = state.NewestDate
+	}
+	if newest == nil {
+		return nil
+	}
+
+	if err := e.syncListPages(ctx, user.ID, fitbit.ListOptions{AfterDate: newest}, list.pager); err != nil {
+		e.saveSyncError(user, list.dataType, err)
+		return err
+	}
+
+	today := truncateDay(time.Now())
+	return user.SaveSyncProgress(e.db.GetDB(), list.dataType, today, today, false)
+}
+
+// syncListHistory will page back through history from the oldest entry saved.
+// Once the start of the history has been reached it is only checked for new entries.
+func (e *Exporter) syncListHistory(user *fitbit.User, list listSync) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Hour)
+	defer cancel()
+
+	state, err := e.client.GetSyncState(user.ID, list.dataType)
+	if err != nil {
+		return err
+	}
+	if state.Complete {
+		return nil
+	}
+	oldest, _, err := list.savedRange(user.ID)
+	if err != nil {
+		return err
+	}
+
+	before := time.Now().Add(24 * time.Hour)
+	if oldest != nil {
+		before = *oldest
+	}
+	if err := e.syncListPages(ctx, user.ID, fitbit.ListOptions{BeforeDate: &before}, list.pager); err != nil {
+		e.saveSyncError(user, list.dataType, err)
+		return err
+	}
+
+	floor, err := memberSince(user)
+	if err != nil {
+		return err
+	}
+	return user.SaveSyncProgress(e.db.GetDB(), list.dataType, floor, truncateDay(time.Now()), true)
+}
+
+// syncListPages will follow the pagination links until no pages remain
+func (e *Exporter) syncListPages(ctx context.Context, userID string, opts fitbit.ListOptions, pager listPager) error {
+	now := time.Now()
+
+	var next string
+	if err := e.withRetry(ctx, userID, now, func() (err error) {
+		next, err = pager.first(ctx, opts)
+		return err
+	}); err != nil {
+		return err
+	}
+
+	for next != "" {
+		link := next
+		if err := e.withRetry(ctx, userID, now, func() (err error) {
+			next, err = pager.next(ctx, link)
+			return err
+		}); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// nextLink returns the link to the following page or nothing when the current page was empty
+func nextLink(entries int, pagination *fitbit.Pagination) string {
+	if entries == 0 || pagination == nil {
+		return ""
+	}
+	return pagination.Next
+}
+
+// activityLogSync saves logged workouts along with the trackpoints from their tcx file
+func (e *Exporter) activityLogSync(user *fitbit.User) listSync {
+	save := func(ctx context.Context, page *fitbit.ActivityLogList) (string, error) {
+		for _, activityLog := range page.Activities {
+			exists, err := user.HasActivityLog(e.db.GetDB(), activityLog.LogID)
+			if err != nil {
+				return "", err
+			}
+			if exists {
+				continue
+			}
+
+			// Manually entered workouts have no recorded track to download
+			var tcx *fitbit.TCX
+			if activityLog.TCXLink != "" && activityLog.LogType != "manual" {
+				if err := e.withRetry(ctx, user.ID, time.Now(), func() (err error) {
+					tcx, err = e.client.GetActivityTCX(user.ID, activityLog.LogID)
+					return err
+				}); err != nil {
+					return "", err
+				}
+			}
+
+			if err := user.SaveActivityLog(e.db.GetDB(), activityLog, tcx); err != nil {
+				return "", err
+			}
+		}
+		return nextLink(len(page.Activities), page.Pagination), nil
+	}
+
+	return listSync{
+		dataType:   syncActivityLogs,
+		savedRange: e.client.GetActivityLogRange,
+		pager: listPager{
+			first: func(ctx context.Context, opts fitbit.ListOptions) (string, error) {
+				page, err := e.client.GetActivityLogs(user.ID, opts)
+				if err != nil {
+					return "", err
+				}
+				return save(ctx, page)
+			},
+			next: func(ctx context.Context, link string) (string, error) {
+				page, err := e.client.GetActivityLogsPage(user.ID, link)
+				if err != nil {
+					return "", err
+				}
+				return save(ctx, page)
+			},
+		},
+	}
+}
+
+// ecgReadingSync saves every ecg reading along with its waveform
+func (e *Exporter) ecgReadingSync(user *fitbit.User) listSync {
+	save := func(page *fitbit.ECGReadingList) (string, error) {
+		if err := user.SaveECGReadings(e.db.GetDB(), page.Readings); err != nil {
+			return "", err
+		}
+		return nextLink(len(page.Readings), page.Pagination), nil
+	}
+
+	return listSync{
+		dataType:   syncECGReadings,
+		savedRange: e.client.GetECGRange,
+		pager: listPager{
+			first: func(ctx context.Context, opts fitbit.ListOptions) (string, error) {
+				page, err := e.client.GetECGReadings(user.ID, opts)
+				if err != nil {
+					return "", err
+				}
+				return save(page)
+			},
+			next: func(ctx context.Context, link string) (string, error) {
+				page, err := e.client.GetECGReadingsPage(user.ID, link)
+				if err != nil {
+					return "", err
+				}
+				return save(page)
+			},
+		},
+	}
+}
+
+// irnAlertSync saves every irregular rhythm notification
+func (e *Exporter) irnAlertSync(user *fitbit.User) listSync {
+	save := func(page *fitbit.IRNAlertList) (string, error) {
+		if err := user.SaveIRNAlerts(e.db.GetDB(), page.Alerts); err != nil {
+			return "", err
+		}
+		return nextLink(len(page.Alerts), page.Pagination), nil
+	}
+
+	return listSync{
+		dataType:   syncIRNAlerts,
+		savedRange: e.client.GetIRNAlertRange,
+		pager: listPager{
+			first: func(ctx context.Context, opts fitbit.ListOptions) (string, error) {
+				page, err := e.client.GetIRNAlerts(user.ID, opts)
+				if err != nil {
+					return "", err
+				}
+				return save(page)
+			},
+			next: func(ctx context.Context, link string) (string, error) {
+				page, err := e.client.GetIRNAlertsPage(user.ID, link)
+				if err != nil {
+					return "", err
+				}
+				return save(page)
+			},
+		},
+	}
+}
@@ -0,0 +1,163 @@
+package exporter
+
+import (
+	"log"
+	"time"
+
+	"github.com/bah2830/fitbit-exporter/pkg/fitbit"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+var (
+	backfillDuration = prometheus.NewGauge(prometheus.GaugeOpts{
+		Namespace: fitbit.MetricsNamespace,
+		Subsystem: fitbit.MetricsSubsystem,
+		Name:      "backfill_duration_seconds",
+		Help:      "Duration of the last completed backfill run.",
+	})
+	backfillLastRun = prometheus.NewGauge(prometheus.GaugeOpts{
+		Namespace: fitbit.MetricsNamespace,
+		Subsystem: fitbit.MetricsSubsystem,
+		Name:      "backfill_last_run_timestamp_seconds",
+		Help:      "Unix timestamp of the last backfill start.",
+	})
+	backfillRunning = prometheus.NewGauge(prometheus.GaugeOpts{
+		Namespace: fitbit.MetricsNamespace,
+		Subsystem: fitbit.MetricsSubsystem,
+		Name:      "backfill_running",
+		Help:      "Whether a backfill is currently running.",
+	})
+	notificationsReceived = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: fitbit.MetricsNamespace,
+		Subsystem: fitbit.MetricsSubsystem,
+		Name:      "notifications_received_total",
+		Help:      "Number of subscription notifications received from fitbit by collection.",
+	}, []string{"collection"})
+	jobsQueued = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: fitbit.MetricsNamespace,
+		Subsystem: fitbit.MetricsSubsystem,
+		Name:      "sync_jobs_queued",
+		Help:      "Number of sync jobs waiting for a worker by queue.",
+	}, []string{"queue"})
+	jobsRunning = prometheus.NewGauge(prometheus.GaugeOpts{
+		Namespace: fitbit.MetricsNamespace,
+		Subsystem: fitbit.MetricsSubsystem,
+		Name:      "sync_jobs_running",
+		Help:      "Number of sync jobs currently running.",
+	})
+)
+
+var (
+	heartRateDesc = prometheus.NewDesc(
+		prometheus.BuildFQName(fitbit.MetricsNamespace, "", "heart_rate_bpm"),
+		"Latest intraday heart rate sample.",
+		[]string{"user_id", "user"}, nil,
+	)
+	heartRateTimeDesc = prometheus.NewDesc(
+		prometheus.BuildFQName(fitbit.MetricsNamespace, "", "heart_rate_timestamp_seconds"),
+		"Unix timestamp of the latest intraday heart rate sample.",
+		[]string{"user_id", "user"}, nil,
+	)
+	restingHeartRateDesc = prometheus.NewDesc(
+		prometheus.BuildFQName(fitbit.MetricsNamespace, "", "resting_heart_rate_bpm"),
+		"Resting heart rate for the current day.",
+		[]string{"user_id", "user"}, nil,
+	)
+	zoneMinutesDesc = prometheus.NewDesc(
+		prometheus.BuildFQName(fitbit.MetricsNamespace, "", "heart_zone_minutes"),
+		"Minutes spent in each heart rate zone for the current day.",
+		[]string{"user_id", "user", "zone"}, nil,
+	)
+	zoneCaloriesDesc = prometheus.NewDesc(
+		prometheus.BuildFQName(fitbit.MetricsNamespace, "", "heart_zone_calories"),
+		"Calories burned in each heart rate zone for the current day.",
+		[]string{"user_id", "user", "zone"}, nil,
+	)
+	deviceBatteryDesc = prometheus.NewDesc(
+		prometheus.BuildFQName(fitbit.MetricsNamespace, "", "device_battery_level_percent"),
+		"Battery level of each paired device.",
+		[]string{"user_id", "user", "device_id", "device_type", "device_version"}, nil,
+	)
+	deviceLastSyncDesc = prometheus.NewDesc(
+		prometheus.BuildFQName(fitbit.MetricsNamespace, "", "device_last_sync_timestamp_seconds"),
+		"Unix timestamp of the last time each paired device synced.",
+		[]string{"user_id", "user", "device_id", "device_type", "device_version"}, nil,
+	)
+)
+
+func init() {
+	prometheus.MustRegister(backfillDuration, backfillLastRun, backfillRunning, notificationsReceived, jobsQueued, jobsRunning)
+}
+
+// userCollector reads the latest stored data for every user from the database on each scrape
+type userCollector struct {
+	client *fitbit.Client
+}
+
+func (c *userCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- heartRateDesc
+	ch <- heartRateTimeDesc
+	ch <- restingHeartRateDesc
+	ch <- zoneMinutesDesc
+	ch <- zoneCaloriesDesc
+	ch <- deviceBatteryDesc
+	ch <- deviceLastSyncDesc
+}
+
+func (c *userCollector) Collect(ch chan<- prometheus.Metric) {
+	for _, user := range c.client.GetUsers() {
+		if err := c.collectUser(ch, user); err != nil {
+			log.Printf("error collecting metrics for %s: %s", user.FullName, err)
+		}
+	}
+}
+
+func (c *userCollector) collectUser(ch chan<- prometheus.Metric, user *fitbit.User) error {
+	latest, err := c.client.GetLatestHeartRate(user.ID)
+	if err != nil {
+		return err
+	}
+	if latest != nil {
+		ch <- prometheus.MustNewConstMetric(heartRateDesc, prometheus.GaugeValue, float64(latest.Value), user.ID, user.FullName)
+
+		sampleTime, err := time.ParseInLocation(dateTimeFormat, latest.Time, time.Local)
+		if err != nil {
+			return err
+		}
+		ch <- prometheus.MustNewConstMetric(heartRateTimeDesc, prometheus.GaugeValue, float64(sampleTime.Unix()), user.ID, user.FullName)
+	}
+
+	resting, err := c.client.GetCurrentResting(user.ID)
+	if err != nil {
+		return err
+	}
+	if resting != 0 {
+		ch <- prometheus.MustNewConstMetric(restingHeartRateDesc, prometheus.GaugeValue, float64(resting), user.ID, user.FullName)
+	}
+
+	zones, err := c.client.GetCurrentDayZones(user.ID)
+	if err != nil {
+		return err
+	}
+	for _, z := range zones {
+		ch <- prometheus.MustNewConstMetric(zoneMinutesDesc, prometheus.GaugeValue, float64(z.Minutes), user.ID, user.FullName, z.Name)
+		ch <- prometheus.MustNewConstMetric(zoneCaloriesDesc, prometheus.GaugeValue, z.CaloriesOut, user.ID, user.FullName, z.Name)
+	}
+
+	devices, err := c.client.GetStoredDevices(user.ID)
+	if err != nil {
+		return err
+	}
+	for _, d := range devices {
+		labels := []string{user.ID, user.FullName, d.ID, d.Type, d.DeviceVersion}
+		ch <- prometheus.MustNewConstMetric(deviceBatteryDesc, prometheus.GaugeValue, float64(d.BatteryLevel), labels...)
+
+		lastSync, err := time.ParseInLocation(dateTimeFormat, d.LastSyncTime, time.Local)
+		if err != nil {
+			return err
+		}
+		ch <- prometheus.MustNewConstMetric(deviceLastSyncDesc, prometheus.GaugeValue, float64(lastSync.Unix()), labels...)
+	}
+
+	return nil
+}
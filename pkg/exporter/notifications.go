@@ -0,0 +1,202 @@
+package exporter
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"github.com/bah2830/fitbit-exporter/pkg/fitbit"
+)
+
+const (
+	// deviceRefreshInterval is how often the devices and lifetime totals are refreshed while the poll interval is
+	// stretched by subscriptions as fitbit sends no notifications for either of them
+	deviceRefreshInterval = time.Hour
+
+	// notificationDelay is how long a notification waits before its data is fetched. Fitbit sends one every time a
+	// tracker syncs so any more for the same collection and day in the meantime are served by the same fetch.
+	notificationDelay = 15 * time.Minute
+)
+
+// Notify queues a fetch of the changed data for each notification without waiting for it.
+// Notifications are served along with the recent data of every user and ones already pending are skipped.
+func (e *Exporter) Notify(notifications []fitbit.Notification) {
+	for _, n := range notifications {
+		notificationsReceived.WithLabelValues(n.CollectionType).Inc()
+
+		n := n
+		key := "notification/" + n.CollectionType + "/" + n.Date
+		if n.CollectionType == fitbit.CollectionUserRevokedAccess {
+			e.queueNotification(key, n)
+			continue
+		}
+
+		pendingKey := n.OwnerID + "/" + key
+		e.pendingMu.Lock()
+		if _, ok := e.pending[pendingKey]; ok {
+			e.pendingMu.Unlock()
+			continue
+		}
+		e.pending[pendingKey] = struct{}{}
+		e.pendingMu.Unlock()
+
+		time.AfterFunc(notificationDelay, func() {
+			e.pendingMu.Lock()
+			delete(e.pending, pendingKey)
+			e.pendingMu.Unlock()
+			e.queueNotification(key, n)
+		})
+	}
+}
+
+// queueNotification queues the fetch of the data changed for the notification
+func (e *Exporter) queueNotification(key string, n fitbit.Notification) {
+	e.queue.add(&job{
+		key:  key,
+		user: n.OwnerID,
+		run: func() (bool, error) {
+			return false, e.fetchNotification(n)
+		},
+	})
+}
+
+// fetchNotification gets and saves the collection that changed for the user on the notification date
+func (e *Exporter) fetchNotification(n fitbit.Notification) error {
+	user, err := e.client.GetUser(n.OwnerID)
+	if err != nil {
+		return err
+	}
+
+	if n.CollectionType == fitbit.CollectionUserRevokedAccess {
+		log.Printf("Access revoked by %s, removing user", user.FullName)
+		return e.client.RemoveUser(user.ID, false)
+	}
+
+	date, err := n.Day()
+	if err != nil {
+		return err
+	}
+
+	// Notifications are for recent data so they may use the budget held in reserve
+	ctx, cancel := context.WithTimeout(fitbit.WithPriority(context.Background(), fitbit.PriorityLive), 3*time.Hour)
+	defer cancel()
+
+	switch n.CollectionType {
+	case fitbit.CollectionActivities:
+		return e.fetchActivities(ctx, user, date)
+	case fitbit.CollectionSleep:
+		if err := e.syncSleep(ctx, user, date, date); err != nil {
+			return err
+		}
+		if !e.cfg.IntradayEnabled(user.ID) {
+			return nil
+		}
+		return e.syncSleepIntraday(ctx, user, date)
+	case fitbit.CollectionBody:
+		return e.backfillBody(ctx, user, date, date)
+	case fitbit.CollectionFoods:
+		return e.backfillNutrition(ctx, user, date)
+	default:
+		log.Printf("Ignoring notification for unknown collection %s", n.CollectionType)
+		return nil
+	}
+}
+
+// fetchActivities will save the activity totals and heart rate for the notification date. Intraday activity is left
+// to the hot window of the next sync as it takes a request per resource.
+func (e *Exporter) fetchActivities(ctx context.Context, user *fitbit.User, date time.Time) error {
+	var totals []*fitbit.ActivityData
+	if err := e.withRetry(ctx, user.ID, date, func() (err error) {
+		totals, err = e.client.GetActivitySummary(user.ID, date)
+		return err
+	}); err != nil {
+		return err
+	}
+	for _, activity := range totals {
+		if err := user.SaveActivityData(e.db.GetDB(), activity); err != nil {
+			return err
+		}
+	}
+
+	azm, err := e.getAZMData(ctx, user.ID, date, date, nil)
+	if err != nil {
+		return err
+	}
+	if err := user.SaveAZMData(e.db.GetDB(), azm); err != nil {
+		return err
+	}
+
+	if !e.cfg.IntradayEnabled(user.ID) {
+		// The heart rate summary is otherwise part of the intraday request
+		return e.refreshHeartSummary(ctx, user, date)
+	}
+
+	// Today only needs the samples since the last saved one
+	detailLevel := fitbit.HeartRateDetailLevel(e.cfg.HeartRateDetailLevel(user.ID))
+	if date.Equal(truncateDay(time.Now())) {
+		return e.refreshHeartData(user, detailLevel)
+	}
+
+	d, err := e.getHeartData(ctx, user.ID, date, detailLevel)
+	if err != nil {
+		return err
+	}
+	return user.SaveHeartRateData(e.db.GetDB(), d)
+}
+
+// refreshHeartSummary will get and save the resting heart rate and zones for the given date
+func (e *Exporter) refreshHeartSummary(ctx context.Context, user *fitbit.User, date time.Time) error {
+	var d *fitbit.HeartRateData
+	if err := e.withRetry(ctx, user.ID, date, func() (err error) {
+		d, err = e.client.GetHeartData(user.ID, fitbit.HeartRateOptions{
+			StartDate: &date,
+			EndDate:   &date,
+		})
+		return err
+	}); err != nil {
+		return err
+	}
+
+	return user.SaveHeartRateData(e.db.GetDB(), d)
+}
+
+// refreshDevicesPeriodically queues a refresh of the devices and lifetime totals of every user on each interval
+func (e *Exporter) refreshDevicesPeriodically() {
+	ticker := time.NewTicker(deviceRefreshInterval)
+	defer ticker.Stop()
+	for range ticker.C {
+		for _, user := range e.client.GetUsers() {
+			user := user
+			e.queue.add(&job{
+				key:  "devices",
+				user: user.ID,
+				run: func() (bool, error) {
+					if err := e.refreshDevices(user); err != nil {
+						return false, err
+					}
+					return false, e.refreshLifetime(user)
+				},
+			})
+		}
+	}
+}
+
+// subscribeUsers queues a subscription for every user so fitbit sends them notifications.
+// Subscribing waits for budget without touching the reserve like the backfill.
+func (e *Exporter) subscribeUsers() {
+	for _, user := range e.client.GetUsers() {
+		user := user
+		e.queue.add(&job{
+			key:  "subscribe",
+			user: user.ID,
+			run: func() (bool, error) {
+				ctx, cancel := context.WithTimeout(context.Background(), 3*time.Hour)
+				defer cancel()
+				if err := e.client.Subscribe(ctx, user.ID); err != nil {
+					log.Printf("Error subscribing to notifications for %s: %s", user.FullName, err)
+				}
+				return false, nil
+			},
+		})
+	}
+}
@@ -0,0 +1,256 @@
+package exporter
+
+import (
+	"sync"
+	"time"
+
+	"github.com/bah2830/fitbit-exporter/pkg/fitbit"
+)
+
+// job is a unit of work for a single user. When run reports more work the job is put back at the
+// front of its queue so a long running sync keeps its place but other users get a turn in between.
+// Only backfill jobs count towards the backfill metrics, notification jobs come and go on their own.
+type job struct {
+	key      string
+	user     string
+	history  bool
+	backfill bool
+	run      func() (bool, error)
+}
+
+// userQueue holds the jobs waiting for a user. Only one job per user runs at a time as every
+// user has their own rate limit budget and more workers would only wait on it.
+type userQueue struct {
+	recent  []*job
+	history []*job
+	keys    map[string]struct{}
+	running bool
+}
+
+// scheduler hands out jobs to the workers going round robin through the users. Recent jobs of every
+// user are served before any history job so a user with years of history doesn't hold up everyone else.
+type scheduler struct {
+	mu     sync.Mutex
+	cond   *sync.Cond
+	users  []string
+	queues map[string]*userQueue
+	next   int
+	closed bool
+	// backfills is the number of backfill jobs queued or running
+	backfills int
+	// wake is the pending wake up for users waiting on budget and wakeAt when it fires
+	wake   *time.Timer
+	wakeAt time.Time
+
+	// budgetWait returns how long history jobs for the user would wait for rate limit budget
+	budgetWait func(user string) time.Duration
+	// idle is called whenever the last backfill job has finished
+	idle func()
+}
+
+func newScheduler(budgetWait func(user string) time.Duration, idle func()) *scheduler {
+	s := &scheduler{
+		queues:     make(map[string]*userQueue),
+		budgetWait: budgetWait,
+		idle:       idle,
+	}
+	s.cond = sync.NewCond(&s.mu)
+	return s
+}
+
+// add queues the job unless a job with the same key is already queued or running for the user
+func (s *scheduler) add(j *job) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	q, ok := s.queues[j.user]
+	if !ok {
+		q = &userQueue{keys: make(map[string]struct{})}
+		s.queues[j.user] = q
+		s.users = append(s.users, j.user)
+	}
+	if _, ok := q.keys[j.key]; ok {
+		return false
+	}
+	q.keys[j.key] = struct{}{}
+	if j.backfill {
+		s.backfills++
+	}
+
+	if j.history {
+		q.history = append(q.history, j)
+	} else {
+		q.recent = append(q.recent, j)
+	}
+
+	s.updateMetrics()
+	s.cond.Signal()
+	return true
+}
+
+// take blocks until a job is available and returns nil once the scheduler is closed
+func (s *scheduler) take() *job {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for !s.closed {
+		if j := s.pick(false); j != nil {
+			return j
+		}
+		if j := s.pick(true); j != nil {
+			return j
+		}
+		s.cond.Wait()
+	}
+	return nil
+}
+
+// pick returns the next job of the kind going round robin from the user after the last one served.
+// History jobs are skipped for users without rate limit budget so they don't tie up a worker.
+// The caller must hold mu.
+func (s *scheduler) pick(history bool) *job {
+	var retry time.Duration
+	for i := 0; i < len(s.users); i++ {
+		index := (s.next + i) % len(s.users)
+		q := s.queues[s.users[index]]
+		if q.running {
+			continue
+		}
+
+		jobs := &q.recent
+		if history {
+			jobs = &q.history
+		}
+		if len(*jobs) == 0 {
+			continue
+		}
+
+		if history {
+			if wait := s.budgetWait(s.users[index]); wait > 0 {
+				if retry == 0 || wait < retry {
+					retry = wait
+				}
+				continue
+			}
+		}
+
+		j := (*jobs)[0]
+		*jobs = (*jobs)[1:]
+		q.running = true
+		s.next = index + 1
+
+		s.updateMetrics()
+		return j
+	}
+
+	// Check again once the first user has budget as nothing else wakes the workers up for it
+	if retry > 0 {
+		s.wakeAfter(retry)
+	}
+	return nil
+}
+
+// wakeAfter wakes every worker once the delay has passed. Only a single wake up is kept pending so workers that
+// find nothing to run don't each add another one. The caller must hold mu.
+func (s *scheduler) wakeAfter(delay time.Duration) {
+	at := time.Now().Add(delay)
+	if s.wake != nil {
+		if !at.Before(s.wakeAt) {
+			return
+		}
+		s.wake.Stop()
+	}
+
+	var timer *time.Timer
+	timer = time.AfterFunc(delay, func() {
+		s.mu.Lock()
+		if s.wake == timer {
+			s.wake = nil
+		}
+		s.mu.Unlock()
+		s.cond.Broadcast()
+	})
+	s.wake = timer
+	s.wakeAt = at
+}
+
+// done marks the job finished for the user putting it back at the front of its queue when it has more to do
+func (s *scheduler) done(j *job, more bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	q := s.queues[j.user]
+	q.running = false
+	if more && !s.closed {
+		if j.history {
+			q.history = append([]*job{j}, q.history...)
+		} else {
+			q.recent = append([]*job{j}, q.recent...)
+		}
+	} else {
+		delete(q.keys, j.key)
+		if j.backfill {
+			s.backfills--
+			if s.backfills == 0 && s.idle != nil {
+				s.idle()
+			}
+		}
+	}
+
+	// Users without any work left are dropped so removed users don't stay in the rotation
+	if len(q.keys) == 0 {
+		s.removeUser(j.user)
+	}
+
+	s.updateMetrics()
+	s.cond.Broadcast()
+}
+
+// removeUser drops the queue of the user. The caller must hold mu.
+func (s *scheduler) removeUser(user string) {
+	delete(s.queues, user)
+	for i, u := range s.users {
+		if u == user {
+			s.users = append(s.users[:i], s.users[i+1:]...)
+			if s.next > i {
+				s.next--
+			}
+			break
+		}
+	}
+}
+
+// busy reports if any backfill job is queued or running
+func (s *scheduler) busy() bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.backfills > 0
+}
+
+// close stops handing out jobs, jobs already running are left to finish
+func (s *scheduler) close() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.closed = true
+	s.cond.Broadcast()
+}
+
+// updateMetrics reports the number of queued and running jobs. The caller must hold mu.
+func (s *scheduler) updateMetrics() {
+	var recent, history, running int
+	for _, q := range s.queues {
+		recent += len(q.recent)
+		history += len(q.history)
+		if q.running {
+			running++
+		}
+	}
+	jobsQueued.WithLabelValues("recent").Set(float64(recent))
+	jobsQueued.WithLabelValues("history").Set(float64(history))
+	jobsRunning.Set(float64(running))
+}
+
+// historyBudgetWait returns how long a history job for the user would wait for rate limit budget
+func (e *Exporter) historyBudgetWait(user string) time.Duration {
+	return e.client.BudgetWait(user, fitbit.PriorityBackfill)
+}
@@ -0,0 +1,180 @@
+package exporter
+
+import (
+	"testing"
+	"time"
+)
+
+func noBudgetWait(string) time.Duration { return 0 }
+
+func newTestJob(user, key string, history bool) *job {
+	return &job{
+		key:      key,
+		user:     user,
+		history:  history,
+		backfill: true,
+		run:      func() (bool, error) { return false, nil },
+	}
+}
+
+// pickNext returns the next job without blocking like take does
+func pickNext(s *scheduler) *job {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if j := s.pick(false); j != nil {
+		return j
+	}
+	return s.pick(true)
+}
+
+func TestSchedulerOrder(t *testing.T) {
+	s := newScheduler(noBudgetWait, nil)
+	for _, user := range []string{"A", "B", "C"} {
+		s.add(newTestJob(user, "history", true))
+		s.add(newTestJob(user, "recent", false))
+	}
+
+	want := []string{"A/recent", "B/recent", "C/recent", "A/history", "B/history", "C/history"}
+	for i, w := range want {
+		j := pickNext(s)
+		if j == nil {
+			t.Fatalf("job %d: got nothing, want %s", i, w)
+		}
+		if got := j.user + "/" + j.key; got != w {
+			t.Errorf("job %d: got %s, want %s", i, got, w)
+		}
+		s.done(j, false)
+	}
+	if j := pickNext(s); j != nil {
+		t.Errorf("got %s/%s after every job was done", j.user, j.key)
+	}
+}
+
+func TestSchedulerOneJobPerUser(t *testing.T) {
+	s := newScheduler(noBudgetWait, nil)
+	s.add(newTestJob("A", "recent", false))
+	s.add(newTestJob("A", "history", true))
+
+	first := pickNext(s)
+	if first == nil || first.key != "recent" {
+		t.Fatalf("got %v, want the recent job", first)
+	}
+	if j := pickNext(s); j != nil {
+		t.Fatalf("got %s while the user already has a job running", j.key)
+	}
+
+	s.done(first, false)
+	if j := pickNext(s); j == nil || j.key != "history" {
+		t.Errorf("got %v, want the history job once the recent job is done", j)
+	}
+}
+
+func TestSchedulerDuplicateKeys(t *testing.T) {
+	s := newScheduler(noBudgetWait, nil)
+	if !s.add(newTestJob("A", "recent", false)) {
+		t.Fatal("first job was not added")
+	}
+	if s.add(newTestJob("A", "recent", false)) {
+		t.Error("job with a queued key was added again")
+	}
+	if !s.add(newTestJob("B", "recent", false)) {
+		t.Error("job with the same key for another user was not added")
+	}
+}
+
+func TestSchedulerRequeue(t *testing.T) {
+	s := newScheduler(noBudgetWait, nil)
+	s.add(newTestJob("A", "history", true))
+	s.add(newTestJob("B", "history", true))
+
+	// A job with more to do goes back in its queue but other users get a turn first
+	want := []string{"A", "B", "A"}
+	for i, w := range want {
+		j := pickNext(s)
+		if j == nil || j.user != w {
+			t.Fatalf("job %d: got %v, want user %s", i, j, w)
+		}
+		s.done(j, j.user == "A" && i == 0)
+	}
+}
+
+func TestSchedulerBudgetWait(t *testing.T) {
+	waits := map[string]time.Duration{"A": time.Hour}
+	s := newScheduler(func(user string) time.Duration { return waits[user] }, nil)
+	s.add(newTestJob("A", "recent", false))
+	s.add(newTestJob("A", "history", true))
+	s.add(newTestJob("B", "history", true))
+	defer func() {
+		if s.wake != nil {
+			s.wake.Stop()
+		}
+	}()
+
+	// Recent jobs are never held back for budget
+	j := pickNext(s)
+	if j == nil || j.user != "A" || j.key != "recent" {
+		t.Fatalf("got %v, want the recent job of A", j)
+	}
+	s.done(j, false)
+
+	// The history of A waits for budget so B goes first
+	running := pickNext(s)
+	if running == nil || running.user != "B" {
+		t.Fatalf("got %v, want the history job of B", running)
+	}
+
+	// Workers finding nothing to run share a single pending wake up
+	var wake *time.Timer
+	for i := 0; i < 3; i++ {
+		if j := pickNext(s); j != nil {
+			t.Fatalf("got %s/%s while A has no budget", j.user, j.key)
+		}
+		if s.wake == nil {
+			t.Fatal("no wake up pending for the user waiting on budget")
+		}
+		if wake != nil && s.wake != wake {
+			t.Error("a later retry replaced the pending wake up")
+		}
+		wake = s.wake
+	}
+
+	// An earlier retry replaces the pending wake up
+	waits["A"] = time.Minute
+	if j := pickNext(s); j != nil {
+		t.Fatalf("got %s/%s while A has no budget", j.user, j.key)
+	}
+	if s.wake == wake || s.wakeAt.After(time.Now().Add(time.Minute)) {
+		t.Error("earlier retry did not replace the pending wake up")
+	}
+
+	waits["A"] = 0
+	if j := pickNext(s); j == nil || j.user != "A" || j.key != "history" {
+		t.Errorf("got %v, want the history job of A once it has budget", j)
+	}
+}
+
+func TestSchedulerIdle(t *testing.T) {
+	var idle int
+	s := newScheduler(noBudgetWait, func() { idle++ })
+
+	notification := &job{key: "notification", user: "A", run: func() (bool, error) { return false, nil }}
+	s.add(notification)
+	s.done(pickNext(s), false)
+	if idle != 0 || s.busy() {
+		t.Errorf("notification job counted as a backfill")
+	}
+
+	s.add(newTestJob("A", "recent", false))
+	s.add(newTestJob("B", "recent", false))
+	if !s.busy() {
+		t.Error("scheduler not busy with queued backfill jobs")
+	}
+	s.done(pickNext(s), false)
+	if idle != 0 {
+		t.Error("idle called while a backfill job is still queued")
+	}
+	s.done(pickNext(s), false)
+	if idle != 1 || s.busy() {
+		t.Errorf("idle called %d times after the last backfill job, want 1", idle)
+	}
+}
@@ -0,0 +1,160 @@
+package exporter
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"github.com/bah2830/fitbit-exporter/pkg/fitbit"
+)
+
+// Data types tracked in the sync state so each backfill can resume where it stopped
+const (
+	syncHeartSummary = "heart_summary"
+	syncDailySummary = "daily_summary"
+	syncDaily        = "daily"
+	syncCardioScore  = "cardio_score"
+	syncActivityLogs = "activity_log"
+	syncECGReadings  = "ecg"
+	syncIRNAlerts    = "irn"
+)
+
+// rangeFetcher gets and saves the data between both dates returning if any data was found
+type rangeFetcher func(ctx context.Context, startDate, endDate time.Time) (bool, error)
+
+// rangeSync walks back through the days of a data type a range at a time recording the synced days after each one.
+// A recent sync covers the hot window and any days since the newest synced day, which may have been partial at
+// the time. A history sync continues back from the oldest synced day so an interrupted backfill resumes where it
+// stopped. The history is complete once floor is reached or, when emptyRanges is set, after that many ranges
+// without data.
+type rangeSync struct {
+	e           *Exporter
+	user        *fitbit.User
+	dataType    string
+	days        int
+	emptyRanges int
+	floor       time.Time
+	fetch       rangeFetcher
+	recent      bool
+
+	endDate           time.Time
+	stopDate          time.Time
+	rangesWithoutData int
+	done              bool
+}
+
+func (e *Exporter) newRangeSync(user *fitbit.User, dataType string, maxRange time.Duration, emptyRanges int, floor time.Time, recent bool, fetch rangeFetcher) (*rangeSync, error) {
+	state, err := e.client.GetSyncState(user.ID, dataType)
+	if err != nil {
+		return nil, err
+	}
+
+	r := &rangeSync{
+		e:           e,
+		user:        user,
+		dataType:    dataType,
+		days:        int(maxRange / (24 * time.Hour)),
+		emptyRanges: emptyRanges,
+		floor:       truncateDay(floor),
+		fetch:       fetch,
+		recent:      recent,
+		endDate:     truncateDay(time.Now()),
+	}
+	r.stopDate = r.floor
+
+	if recent {
+		// Data for recent days keeps arriving as the tracker syncs
+		r.stopDate = r.endDate.AddDate(0, 0, -(e.cfg.HotWindowDays() - 1))
+		if state.NewestDate != nil && state.NewestDate.Before(r.stopDate) {
+			r.stopDate = *state.NewestDate
+		}
+		if r.stopDate.Before(r.floor) {
+			r.stopDate = r.floor
+		}
+		return r, nil
+	}
+
+	if state.Complete {
+		r.done = true
+	} else if state.OldestDate != nil {
+		r.endDate = state.OldestDate.AddDate(0, 0, -1)
+	}
+	return r, nil
+}
+
+// step fetches the next range returning true once there is nothing left to sync
+func (r *rangeSync) step() (bool, error) {
+	if r.done {
+		return true, nil
+	}
+	if r.endDate.Before(r.stopDate) {
+		r.done = true
+		if !r.recent {
+			return true, r.user.SaveSyncProgress(r.e.db.GetDB(), r.dataType, r.floor, r.floor, true)
+		}
+		return true, nil
+	}
+
+	// Ranges include both the start and end date
+	startDate := r.endDate.AddDate(0, 0, -(r.days - 1))
+	if startDate.Before(r.stopDate) {
+		startDate = r.stopDate
+	}
+
+	// Recent days are what the metrics show so they may use the calls held back from the backfill
+	ctx := context.Background()
+	if r.recent {
+		ctx = fitbit.WithPriority(ctx, fitbit.PriorityLive)
+	}
+	ctx, cancel := context.WithTimeout(ctx, 3*time.Hour)
+	hasData, err := r.fetch(ctx, startDate, r.endDate)
+	cancel()
+	if err != nil {
+		r.e.saveSyncError(r.user, r.dataType, err)
+		return false, err
+	}
+
+	// Empty recent days are expected and say nothing about the end of the history
+	if hasData {
+		r.rangesWithoutData = 0
+	} else {
+		r.rangesWithoutData++
+	}
+	// Only the history sync can complete a data type, a recent sync may run before the floor is known
+	complete := !r.recent && (!startDate.After(r.floor) || (r.emptyRanges > 0 && r.rangesWithoutData >= r.emptyRanges))
+	if err := r.user.SaveSyncProgress(r.e.db.GetDB(), r.dataType, startDate, r.endDate, complete); err != nil {
+		return false, err
+	}
+
+	r.endDate = startDate.AddDate(0, 0, -1)
+	r.done = complete || r.endDate.Before(r.stopDate)
+	return r.done, nil
+}
+
+// run steps through every range
+func (r *rangeSync) run() error {
+	for {
+		done, err := r.step()
+		if err != nil || done {
+			return err
+		}
+	}
+}
+
+// saveSyncError records the error for the data type, failures are only logged so the original error is kept
+func (e *Exporter) saveSyncError(user *fitbit.User, dataType string, syncErr error) {
+	if err := user.SaveSyncError(e.db.GetDB(), dataType, syncErr); err != nil {
+		log.Printf("error saving %s sync error for %s: %s", dataType, user.ID, err)
+	}
+}
+
+// memberSince returns the day the user joined fitbit which is the furthest back any history can go
+func memberSince(user *fitbit.User) (time.Time, error) {
+	return time.ParseInLocation(dateFormat, user.MemberSince, time.Local)
+}
+
+// truncateDay returns midnight of the given day in local time
+func truncateDay(t time.Time) time.Time {
+	year, month, day := t.Date()
+	return time.Date(year, month, day, 0, 0, 0, 0, time.Local)
+}
@@ -0,0 +1,283 @@
+package fitbit
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+)
+
+const (
+	activityPath        = "/user/%s/activities/%s/date"
+	activitySummaryPath = "/user/%s/activities/date/%s.json"
+
+	ActivityResourceSteps     ActivityResource = "steps"
+	ActivityResourceDistance  ActivityResource = "distance"
+	ActivityResourceFloors    ActivityResource = "floors"
+	ActivityResourceElevation ActivityResource = "elevation"
+	ActivityResourceCalories  ActivityResource = "calories"
+
+	ActivityDetailLevel1Min  ActivityDetailLevel = "1min"
+	ActivityDetailLevel15Min ActivityDetailLevel = "15min"
+)
+
+// ActivityResources is every activity time series collected by the exporter
+var ActivityResources = []ActivityResource{
+	ActivityResourceSteps,
+	ActivityResourceDistance,
+	ActivityResourceFloors,
+	ActivityResourceElevation,
+	ActivityResourceCalories,
+}
+
+type ActivityResource string
+type ActivityDetailLevel string
+
+type ActivityOptions struct {
+	Resource    ActivityResource
+	StartDate   *time.Time
+	EndDate     *time.Time
+	DetailLevel *ActivityDetailLevel
+}
+
+type ActivityData struct {
+	Resource      ActivityResource
+	OverviewByDay []ActivityOverview
+	IntraDay      *ActivityIntraDay
+}
+
+type ActivityOverview struct {
+	Date  string  `json:"dateTime"`
+	Value float64 `json:"value,string"`
+}
+
+type ActivityIntraDay struct {
+	Data             []ActivityPoint `json:"dataset"`
+	DataInterval     int             `json:"datasetInterval"`
+	DataIntervalType string          `json:"datasetType"`
+}
+
+type ActivityPoint struct {
+	Time  string  `json:"time"`
+	Value float64 `json:"value"`
+}
+
+type activitySummaryResponse struct {
+	Summary struct {
+		Steps       float64 `json:"steps"`
+		Floors      float64 `json:"floors"`
+		Elevation   float64 `json:"elevation"`
+		CaloriesOut float64 `json:"caloriesOut"`
+		Distances   []struct {
+			Activity string  `json:"activity"`
+			Distance float64 `json:"distance"`
+		} `json:"distances"`
+	} `json:"summary"`
+}
+
+func (c *Client) GetActivityData(user string, opts ActivityOptions) (*ActivityData, error) {
+	path, err := opts.toPath(user)
+	if err != nil {
+		return nil, err
+	}
+
+	userClient, err := c.GetUser(user)
+	if err != nil {
+		return nil, err
+	}
+
+	// The response keys are named after the resource requested so they have to be picked out by hand
+	raw := make(map[string]json.RawMessage)
+	if err := c.get(userClient.httpClient, path, &raw); err != nil {
+		return nil, err
+	}
+
+	data := &ActivityData{Resource: opts.Resource}
+	if overview, ok := raw["activities-"+string(opts.Resource)]; ok {
+		if err := json.Unmarshal(overview, &data.OverviewByDay); err != nil {
+			return nil, err
+		}
+	}
+	if intraday, ok := raw["activities-"+string(opts.Resource)+"-intraday"]; ok {
+		data.IntraDay = &ActivityIntraDay{}
+		if err := json.Unmarshal(intraday, data.IntraDay); err != nil {
+			return nil, err
+		}
+	}
+
+	return data, nil
+}
+
+// GetActivitySummary gets the totals of every activity resource for the date with a single request.
+// The totals are returned as one overview per resource so they are saved the same as the time series.
+func (c *Client) GetActivitySummary(user string, date time.Time) ([]*ActivityData, error) {
+	userClient, err := c.GetUser(user)
+	if err != nil {
+		return nil, err
+	}
+
+	var resp activitySummaryResponse
+	if err := c.get(userClient.httpClient, basePath+fmt.Sprintf(activitySummaryPath, user, date.Format("2006-01-02")), &resp); err != nil {
+		return nil, err
+	}
+
+	totals := map[ActivityResource]float64{
+		ActivityResourceSteps:     resp.Summary.Steps,
+		ActivityResourceFloors:    resp.Summary.Floors,
+		ActivityResourceElevation: resp.Summary.Elevation,
+		ActivityResourceCalories:  resp.Summary.CaloriesOut,
+	}
+	for _, distance := range resp.Summary.Distances {
+		if distance.Activity == "total" {
+			totals[ActivityResourceDistance] = distance.Distance
+		}
+	}
+
+	data := make([]*ActivityData, 0, len(ActivityResources))
+	for _, resource := range ActivityResources {
+		data = append(data, &ActivityData{
+			Resource:      resource,
+			OverviewByDay: []ActivityOverview{{Date: date.Format("2006-01-02"), Value: totals[resource]}},
+		})
+	}
+	return data, nil
+}
+
+func (o ActivityOptions) toPath(user string) (string, error) {
+	if o.Resource == "" {
+		return "", fmt.Errorf("activity resource not given")
+	}
+
+	path := basePath + fmt.Sprintf(activityPath, user, o.Resource)
+
+	if o.StartDate == nil {
+		path += "/today"
+	} else {
+		path += "/" + o.StartDate.Format("2006-01-02")
+	}
+
+	// Intraday data can only be requested a single day at a time
+	if o.DetailLevel != nil {
+		path += "/1d/" + string(*o.DetailLevel)
+	} else if o.EndDate == nil {
+		path += "/1d"
+	} else {
+		path += "/" + o.EndDate.Format("2006-01-02")
+	}
+
+	path += ".json"
+
+	return path, nil
+}
+
+func GetActivityDetailLevel(level ActivityDetailLevel) *ActivityDetailLevel {
+	return &level
+}
+
+func (u *User) SaveActivityData(db *sql.DB, data *ActivityData) error {
+	var day string
+	for _, dayOverview := range data.OverviewByDay {
+		day = dayOverview.Date
+
+		// Totals of recent days change as the tracker syncs so replace the saved value
+		if dayOverview.Value != 0 {
+			_, err := db.Exec(
+				"insert into activity_daily (user_id, date, resource, value) values (?, ?, ?, ?) on duplicate key update value = values(value)",
+				u.ID,
+				day,
+				data.Resource,
+				dayOverview.Value,
+			)
+			if err != nil {
+				return err
+			}
+		}
+	}
+
+	if data.IntraDay == nil || len(data.IntraDay.Data) == 0 {
+		return nil
+	}
+
+	values := make([]string, 0, len(data.IntraDay.Data))
+	for _, d := range data.IntraDay.Data {
+		// Idle minutes make up most of the day so only store the ones with activity
+		if d.Value == 0 {
+			continue
+		}
+		values = append(values, fmt.Sprintf("('%s', '%s', '%s', %f)", u.ID, day+" "+d.Time, data.Resource, d.Value))
+	}
+
+	// Insert 200 data points at a time to help take load off the database connection
+	insertQuery := "insert into activity_data (user_id, date, resource, value) values "
+	for i := 0; i < len(values); i += 200 {
+		end := i + 200
+		if end > len(values) {
+			end = len(values)
+		}
+		if _, err := db.Exec(insertQuery + strings.Join(values[i:end], ", ") + " on duplicate key update value = values(value)"); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (c *Client) GetCurrentDayActivity(user string) (map[ActivityResource]float64, error) {
+	query := `select
+		resource,
+		value
+	from activity_daily
+	where
+		user_id = ?
+	and date_format(date, '%Y-%m-%d') = date_format(now(), '%Y-%m-%d')`
+
+	rows, err := c.db.GetDB().Query(query, user)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	results := make(map[ActivityResource]float64)
+	for rows.Next() {
+		var resource string
+		var value float64
+		if err := rows.Scan(&resource, &value); err != nil {
+			return nil, err
+		}
+		results[ActivityResource(resource)] = value
+	}
+	return results, nil
+}
+
+func (c *Client) GetActivityByDate(user string, resource ActivityResource, startDate, endDate time.Time) ([]ActivityOverview, error) {
+	query := `select
+		date_format(date, '%Y-%m-%d'),
+		value
+	from activity_daily
+	where
+		user_id = ?
+	and resource = ?
+	and date between ? and ?
+	order by date`
+
+	rows, err := c.db.GetDB().Query(query, user, resource, startDate.Format("2006-01-02"), endDate.Format("2006-01-02"))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	results := make([]ActivityOverview, 0, 31)
+	for rows.Next() {
+		var date string
+		var value float64
+		if err := rows.Scan(&date, &value); err != nil {
+			return nil, err
+		}
+		results = append(results, ActivityOverview{
+			Date:  date,
+			Value: value,
+		})
+	}
+	return results, nil
+}
@@ -0,0 +1,236 @@
+package fitbit
+
+import (
+	"database/sql"
+	"encoding/xml"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/bah2830/fitbit-exporter/pkg/database"
+)
+
+const (
+	activityLogListPath = "/user/%s/activities/list.json"
+	activityLogTCXPath  = "/user/%s/activities/%d.tcx"
+
+	// activityLogPageLimit is the largest page size allowed by the api
+	activityLogPageLimit = 100
+)
+
+type ActivityLogList struct {
+	Activities []ActivityLog `json:"activities"`
+	Pagination *Pagination   `json:"pagination"`
+}
+
+type ActivityLog struct {
+	LogID            int64   `json:"logId"`
+	ActivityName     string  `json:"activityName"`
+	ActivityTypeID   int     `json:"activityTypeId"`
+	LogType          string  `json:"logType"`
+	StartTime        string  `json:"startTime"`
+	Duration         int64   `json:"duration"`
+	ActiveDuration   int64   `json:"activeDuration"`
+	Calories         int     `json:"calories"`
+	Distance         float64 `json:"distance"`
+	DistanceUnit     string  `json:"distanceUnit"`
+	Steps            int     `json:"steps"`
+	AverageHeartRate int     `json:"averageHeartRate"`
+	ElevationGain    float64 `json:"elevationGain"`
+	TCXLink          string  `json:"tcxLink"`
+}
+
+// TCX is the subset of the Training Center XML format returned for a logged activity
+type TCX struct {
+	Activities []TCXActivity `xml:"Activities>Activity"`
+}
+
+type TCXActivity struct {
+	Sport string   `xml:"Sport,attr"`
+	Laps  []TCXLap `xml:"Lap"`
+}
+
+type TCXLap struct {
+	StartTime   string          `xml:"StartTime,attr"`
+	Trackpoints []TCXTrackpoint `xml:"Track>Trackpoint"`
+}
+
+type TCXTrackpoint struct {
+	Time      string       `xml:"Time"`
+	Position  *TCXPosition `xml:"Position"`
+	Altitude  *float64     `xml:"AltitudeMeters"`
+	Distance  *float64     `xml:"DistanceMeters"`
+	HeartRate *int         `xml:"HeartRateBpm>Value"`
+}
+
+type TCXPosition struct {
+	Latitude  float64 `xml:"LatitudeDegrees"`
+	Longitude float64 `xml:"LongitudeDegrees"`
+}
+
+func (c *Client) GetActivityLogs(user string, opts ListOptions) (*ActivityLogList, error) {
+	path, err := opts.toPath(fmt.Sprintf(activityLogListPath, user), activityLogPageLimit)
+	if err != nil {
+		return nil, err
+	}
+
+	return c.GetActivityLogsPage(user, path)
+}
+
+// GetActivityLogsPage gets a page of activity logs from a pagination link of a previous response
+func (c *Client) GetActivityLogsPage(user string, link string) (*ActivityLogList, error) {
+	userClient, err := c.GetUser(user)
+	if err != nil {
+		return nil, err
+	}
+
+	data := &ActivityLogList{}
+	if err := c.get(userClient.httpClient, link, data); err != nil {
+		return nil, err
+	}
+
+	return data, nil
+}
+
+func (c *Client) GetActivityTCX(user string, logID int64) (*TCX, error) {
+	userClient, err := c.GetUser(user)
+	if err != nil {
+		return nil, err
+	}
+
+	b, err := c.getRaw(userClient.httpClient, basePath+fmt.Sprintf(activityLogTCXPath, user, logID))
+	if err != nil {
+		return nil, err
+	}
+
+	data := &TCX{}
+	if err := xml.Unmarshal(b, data); err != nil {
+		return nil, err
+	}
+
+	return data, nil
+}
+
+// HasActivityLog returns if the log has already been saved
+func (u *User) HasActivityLog(db *sql.DB, logID int64) (bool, error) {
+	var count int
+	if err := db.QueryRow("select count(*) from activity_log where log_id = ?", logID).Scan(&count); err != nil {
+		return false, err
+	}
+	return count > 0, nil
+}
+
+func (u *User) SaveActivityLog(db *sql.DB, log ActivityLog, tcx *TCX) error {
+	startTime, err := time.Parse(time.RFC3339, log.StartTime)
+	if err != nil {
+		return err
+	}
+
+	tx, err := db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	insertStatement := `insert into activity_log
+	(log_id, user_id, name, activity_type_id, log_type, start_time, duration, active_duration,
+	calories, distance, distance_unit, steps, average_heart_rate, elevation_gain)
+	values (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`
+
+	// Start times are kept in the timezone the activity was recorded in
+	if _, err := tx.Exec(
+		insertStatement,
+		log.LogID,
+		u.ID,
+		log.ActivityName,
+		log.ActivityTypeID,
+		log.LogType,
+		startTime.Format(database.DateTimeFormat),
+		log.Duration/1000,
+		log.ActiveDuration/1000,
+		log.Calories,
+		log.Distance,
+		log.DistanceUnit,
+		log.Steps,
+		log.AverageHeartRate,
+		log.ElevationGain,
+	); err != nil {
+		return err
+	}
+
+	if tcx != nil {
+		if err := saveTrackpoints(tx, log.LogID, tcx); err != nil {
+			return err
+		}
+	}
+
+	return tx.Commit()
+}
+
+func saveTrackpoints(tx *sql.Tx, logID int64, tcx *TCX) error {
+	// Trackpoints can repeat a timestamp across lap boundaries so only the first is kept
+	seen := make(map[string]struct{})
+	values := make([]string, 0)
+	for _, activity := range tcx.Activities {
+		for _, lap := range activity.Laps {
+			for _, point := range lap.Trackpoints {
+				t, err := time.Parse(time.RFC3339, point.Time)
+				if err != nil {
+					return err
+				}
+				date := t.Format(database.DateTimeFormat)
+				if _, ok := seen[date]; ok {
+					continue
+				}
+				seen[date] = struct{}{}
+
+				lat, lon := "NULL", "NULL"
+				if point.Position != nil {
+					lat = strconv.FormatFloat(point.Position.Latitude, 'f', -1, 64)
+					lon = strconv.FormatFloat(point.Position.Longitude, 'f', -1, 64)
+				}
+				altitude, distance, heartRate := "NULL", "NULL", "NULL"
+				if point.Altitude != nil {
+					altitude = strconv.FormatFloat(*point.Altitude, 'f', -1, 64)
+				}
+				if point.Distance != nil {
+					distance = strconv.FormatFloat(*point.Distance, 'f', -1, 64)
+				}
+				if point.HeartRate != nil {
+					heartRate = strconv.Itoa(*point.HeartRate)
+				}
+
+				values = append(values, fmt.Sprintf(
+					"(%d, '%s', %s, %s, %s, %s, %s)",
+					logID,
+					date,
+					lat,
+					lon,
+					altitude,
+					distance,
+					heartRate,
+				))
+			}
+		}
+	}
+
+	// Insert 200 trackpoints at a time to help take load off the database connection
+	insertQuery := "insert into activity_trackpoint (log_id, date, latitude, longitude, altitude, distance, heart_rate) values "
+	for i := 0; i < len(values); i += 200 {
+		end := i + 200
+		if end > len(values) {
+			end = len(values)
+		}
+		if _, err := tx.Exec(insertQuery + strings.Join(values[i:end], ", ")); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// GetActivityLogRange returns the start times of the oldest and newest saved activity logs
+func (c *Client) GetActivityLogRange(user string) (*time.Time, *time.Time, error) {
+	return c.getSavedRange("activity_log", "start_time", user)
+}
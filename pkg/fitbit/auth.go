@@ -0,0 +1,206 @@
+package fitbit
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"errors"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"golang.org/x/oauth2"
+)
+
+const (
+	authCookieName = "fitbit_oauth"
+
+	// authStateTTL is how long a user has to complete the fitbit login
+	authStateTTL = 10 * time.Minute
+)
+
+// AuthError is returned when the callback can not be matched to a login started by this server
+type AuthError struct {
+	Reason string
+}
+
+func (e *AuthError) Error() string {
+	return "invalid login attempt: " + e.Reason
+}
+
+// authAttempt holds the values tying a login redirect to its callback
+type authAttempt struct {
+	nonce    string
+	expiry   int64
+	verifier string
+}
+
+func newAuthAttempt() (*authAttempt, error) {
+	nonce, err := randomString(16)
+	if err != nil {
+		return nil, err
+	}
+	verifier, err := randomString(32)
+	if err != nil {
+		return nil, err
+	}
+
+	return &authAttempt{
+		nonce:    nonce,
+		expiry:   time.Now().Add(authStateTTL).Unix(),
+		verifier: verifier,
+	}, nil
+}
+
+// state is sent to fitbit and signed over the cookie contents so it can only be used with the matching cookie
+func (a *authAttempt) state(key []byte) string {
+	return a.nonce + "." + strconv.FormatInt(a.expiry, 10) + "." + a.signature(key)
+}
+
+func (a *authAttempt) cookieValue() string {
+	return a.nonce + "." + strconv.FormatInt(a.expiry, 10) + "." + a.verifier
+}
+
+func (a *authAttempt) signature(key []byte) string {
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte(a.cookieValue()))
+	return base64.RawURLEncoding.EncodeToString(mac.Sum(nil))
+}
+
+// challenge is the S256 PKCE code challenge for the verifier
+func (a *authAttempt) challenge() string {
+	sum := sha256.Sum256([]byte(a.verifier))
+	return base64.RawURLEncoding.EncodeToString(sum[:])
+}
+
+func parseAuthCookie(value string) (*authAttempt, error) {
+	parts := strings.Split(value, ".")
+	if len(parts) != 3 {
+		return nil, &AuthError{Reason: "malformed login cookie"}
+	}
+
+	expiry, err := strconv.ParseInt(parts[1], 10, 64)
+	if err != nil {
+		return nil, &AuthError{Reason: "malformed login cookie"}
+	}
+
+	return &authAttempt{
+		nonce:    parts[0],
+		expiry:   expiry,
+		verifier: parts[2],
+	}, nil
+}
+
+// verify checks the state returned by fitbit was created for this attempt and has not expired
+func (a *authAttempt) verify(key []byte, state string) error {
+	if !hmac.Equal([]byte(state), []byte(a.state(key))) {
+		return &AuthError{Reason: "state does not match"}
+	}
+	if time.Now().Unix() > a.expiry {
+		return &AuthError{Reason: "login expired"}
+	}
+	return nil
+}
+
+func (c *Client) LoginHandler(w http.ResponseWriter, r *http.Request) {
+	attempt, err := newAuthAttempt()
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	http.SetCookie(w, &http.Cookie{
+		Name:     authCookieName,
+		Value:    attempt.cookieValue(),
+		Path:     c.cfg.PublicPath(),
+		Expires:  time.Unix(attempt.expiry, 0),
+		HttpOnly: true,
+		Secure:   r.TLS != nil || strings.HasPrefix(c.cfg.PublicURL(""), "https://"),
+		SameSite: http.SameSiteLaxMode,
+	})
+
+	url := c.oauthConfig.AuthCodeURL(
+		attempt.state(c.sessionKey),
+		oauth2.SetAuthURLParam("code_challenge", attempt.challenge()),
+		oauth2.SetAuthURLParam("code_challenge_method", "S256"),
+	)
+	http.Redirect(w, r, url, http.StatusTemporaryRedirect)
+}
+
+// HandleCallback validates the login callback against the cookie set by LoginHandler and adds the logged in user
+func (c *Client) HandleCallback(w http.ResponseWriter, r *http.Request) (*User, error) {
+	cookie, err := r.Cookie(authCookieName)
+	if err != nil {
+		return nil, &AuthError{Reason: "login cookie not found"}
+	}
+
+	// The attempt can only be used once
+	http.SetCookie(w, &http.Cookie{
+		Name:     authCookieName,
+		Path:     c.cfg.PublicPath(),
+		MaxAge:   -1,
+		HttpOnly: true,
+	})
+
+	attempt, err := parseAuthCookie(cookie.Value)
+	if err != nil {
+		return nil, err
+	}
+	if err := attempt.verify(c.sessionKey, r.FormValue("state")); err != nil {
+		return nil, err
+	}
+
+	// The error is not included as it is user controlled and shown on the error page
+	if r.FormValue("error") != "" {
+		return nil, errors.New("fitbit login was denied or failed")
+	}
+
+	token, err := c.oauthConfig.Exchange(
+		oauth2.NoContext,
+		r.FormValue("code"),
+		oauth2.SetAuthURLParam("code_verifier", attempt.verifier),
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	user, err := c.GetCurrentUser(c.oauthConfig.Client(oauth2.NoContext, token))
+	if err != nil {
+		return nil, err
+	}
+
+	user.token = token
+	user.httpClient = c.newUserHTTPClient(user)
+	c.addUser(user)
+
+	if err := user.save(c.db.GetDB()); err != nil {
+		return nil, err
+	}
+	if err := user.saveToken(c.db.GetDB()); err != nil {
+		return nil, err
+	}
+
+	// The hourly sync still picks up the data if fitbit can't be subscribed to
+	if c.cfg.Fitbit.Subscriptions.Enabled {
+		// The user is waiting on the login so it may use the budget held in reserve
+		ctx, cancel := context.WithTimeout(WithPriority(context.Background(), PriorityLive), time.Minute)
+		defer cancel()
+		if err := c.Subscribe(ctx, user.ID); err != nil {
+			log.Printf("error subscribing to notifications for %s: %s", user.ID, err)
+		}
+	}
+
+	return user, nil
+}
+
+func randomString(size int) (string, error) {
+	b := make([]byte, size)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(b), nil
+}
@@ -0,0 +1,95 @@
+package fitbit
+
+import (
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestAuthAttemptVerify(t *testing.T) {
+	key := []byte("session-key")
+
+	attempt, err := newAuthAttempt()
+	if err != nil {
+		t.Fatal(err)
+	}
+	other, err := newAuthAttempt()
+	if err != nil {
+		t.Fatal(err)
+	}
+	expired := &authAttempt{nonce: attempt.nonce, expiry: time.Now().Add(-time.Minute).Unix(), verifier: attempt.verifier}
+
+	state := attempt.state(key)
+	tampered := state[:len(state)-1] + "A"
+	if strings.HasSuffix(state, "A") {
+		tampered = state[:len(state)-1] + "B"
+	}
+
+	// The cookie has its expiry pushed back without the state being signed for it
+	extended := attempt.nonce + "." + strconv.FormatInt(time.Now().Add(time.Hour).Unix(), 10) + "." + attempt.verifier
+
+	tests := []struct {
+		name    string
+		cookie  string
+		state   string
+		key     []byte
+		wantErr string
+	}{
+		{"valid", attempt.cookieValue(), state, key, ""},
+		{"expired", expired.cookieValue(), expired.state(key), key, "login expired"},
+		{"tampered signature", attempt.cookieValue(), tampered, key, "state does not match"},
+		{"state of another login", attempt.cookieValue(), other.state(key), key, "state does not match"},
+		{"cookie of another login", other.cookieValue(), state, key, "state does not match"},
+		{"tampered cookie", extended, state, key, "state does not match"},
+		{"signed with another key", attempt.cookieValue(), attempt.state([]byte("other-key")), key, "state does not match"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			cookie, err := parseAuthCookie(test.cookie)
+			if err != nil {
+				t.Fatalf("parseAuthCookie() error = %v", err)
+			}
+
+			err = cookie.verify(test.key, test.state)
+			if test.wantErr == "" {
+				if err != nil {
+					t.Errorf("verify() error = %v, want nil", err)
+				}
+				return
+			}
+
+			authErr, ok := err.(*AuthError)
+			if !ok || authErr.Reason != test.wantErr {
+				t.Errorf("verify() error = %v, want %q", err, test.wantErr)
+			}
+		})
+	}
+}
+
+func TestParseAuthCookie(t *testing.T) {
+	tests := []struct {
+		name    string
+		value   string
+		wantErr bool
+	}{
+		{"valid", "nonce.1767225600.verifier", false},
+		{"missing part", "nonce.1767225600", true},
+		{"extra part", "nonce.1767225600.verifier.extra", true},
+		{"expiry not a number", "nonce.soon.verifier", true},
+		{"empty", "", true},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			attempt, err := parseAuthCookie(test.value)
+			if (err != nil) != test.wantErr {
+				t.Fatalf("parseAuthCookie() error = %v, want error %t", err, test.wantErr)
+			}
+			if err == nil && attempt.cookieValue() != test.value {
+				t.Errorf("cookie value = %q, want %q", attempt.cookieValue(), test.value)
+			}
+		})
+	}
+}
@@ -0,0 +1,184 @@
+package fitbit
+
+import (
+	"database/sql"
+	"fmt"
+	"strings"
+	"time"
+)
+
+const azmPath = "/user/%s/activities/active-zone-minutes/date"
+
+type AZMOptions struct {
+	StartDate   *time.Time
+	EndDate     *time.Time
+	DetailLevel *ActivityDetailLevel
+}
+
+type AZMData struct {
+	OverviewByDay []AZMOverView `json:"activities-active-zone-minutes"`
+	IntraDay      []AZMIntraDay `json:"activities-active-zone-minutes-intraday"`
+}
+
+type AZMOverView struct {
+	Date  string   `json:"dateTime"`
+	Value AZMValue `json:"value"`
+}
+
+type AZMIntraDay struct {
+	Date    string      `json:"dateTime"`
+	Minutes []AZMMinute `json:"minutes"`
+}
+
+type AZMMinute struct {
+	Minute string   `json:"minute"`
+	Value  AZMValue `json:"value"`
+}
+
+// AZMValue holds credited minutes. Cardio and peak minutes already include their 2x multiplier
+// so they will not line up with the minutes spent in the matching heart rate zone.
+type AZMValue struct {
+	ActiveZoneMinutes int `json:"activeZoneMinutes"`
+	FatBurn           int `json:"fatBurnActiveZoneMinutes"`
+	Cardio            int `json:"cardioActiveZoneMinutes"`
+	Peak              int `json:"peakActiveZoneMinutes"`
+}
+
+func (c *Client) GetActiveZoneMinutes(user string, opts AZMOptions) (*AZMData, error) {
+	path, err := opts.toPath(user)
+	if err != nil {
+		return nil, err
+	}
+
+	userClient, err := c.GetUser(user)
+	if err != nil {
+		return nil, err
+	}
+
+	data := &AZMData{}
+	if err := c.get(userClient.httpClient, path, data); err != nil {
+		return nil, err
+	}
+
+	return data, nil
+}
+
+func (o AZMOptions) toPath(user string) (string, error) {
+	path := basePath + fmt.Sprintf(azmPath, user)
+
+	if o.StartDate == nil {
+		path += "/today"
+	} else {
+		path += "/" + o.StartDate.Format("2006-01-02")
+	}
+
+	// Intraday data can only be requested a single day at a time
+	if o.DetailLevel != nil {
+		path += "/1d/" + string(*o.DetailLevel)
+	} else if o.EndDate == nil {
+		path += "/1d"
+	} else {
+		path += "/" + o.EndDate.Format("2006-01-02")
+	}
+
+	path += ".json"
+
+	return path, nil
+}
+
+func (u *User) SaveAZMData(db *sql.DB, data *AZMData) error {
+	for _, day := range data.OverviewByDay {
+		if day.Value.ActiveZoneMinutes == 0 {
+			continue
+		}
+
+		_, err := db.Exec(
+			`insert into azm_daily (user_id, date, total, fat_burn, cardio, peak) values (?, ?, ?, ?, ?, ?)
+			on duplicate key update total = values(total), fat_burn = values(fat_burn), cardio = values(cardio), peak = values(peak)`,
+			u.ID,
+			day.Date,
+			day.Value.ActiveZoneMinutes,
+			day.Value.FatBurn,
+			day.Value.Cardio,
+			day.Value.Peak,
+		)
+		if err != nil {
+			return err
+		}
+	}
+
+	for _, day := range data.IntraDay {
+		if err := u.saveAZMIntraDay(db, day); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (u *User) saveAZMIntraDay(db *sql.DB, day AZMIntraDay) error {
+	if len(day.Minutes) == 0 {
+		return nil
+	}
+
+	values := make([]string, 0, len(day.Minutes))
+	for _, m := range day.Minutes {
+		if m.Value.ActiveZoneMinutes == 0 {
+			continue
+		}
+
+		date, err := formatFitbitDateTime(m.Minute)
+		if err != nil {
+			return err
+		}
+		values = append(values, fmt.Sprintf(
+			"('%s', '%s', %d, %d, %d, %d)",
+			u.ID,
+			date,
+			m.Value.ActiveZoneMinutes,
+			m.Value.FatBurn,
+			m.Value.Cardio,
+			m.Value.Peak,
+		))
+	}
+
+	// Insert 200 data points at a time to help take load off the database connection
+	insertQuery := "insert into azm_intraday (user_id, date, total, fat_burn, cardio, peak) values "
+	updateQuery := " on duplicate key update total = values(total), fat_burn = values(fat_burn), cardio = values(cardio), peak = values(peak)"
+	for i := 0; i < len(values); i += 200 {
+		end := i + 200
+		if end > len(values) {
+			end = len(values)
+		}
+		if _, err := db.Exec(insertQuery + strings.Join(values[i:end], ", ") + updateQuery); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// GetAZMTotalsByDate returns the sum of active zone minutes between the given dates
+func (c *Client) GetAZMTotalsByDate(user string, startDate, endDate time.Time) (*AZMValue, error) {
+	query := `select
+		coalesce(sum(total), 0),
+		coalesce(sum(fat_burn), 0),
+		coalesce(sum(cardio), 0),
+		coalesce(sum(peak), 0)
+	from azm_daily
+	where
+		user_id = ?
+	and date between ? and ?`
+
+	result := &AZMValue{}
+	if err := c.db.GetDB().QueryRow(
+		query,
+		user,
+		startDate.Format("2006-01-02"),
+		endDate.Format("2006-01-02"),
+	).Scan(&result.ActiveZoneMinutes, &result.FatBurn, &result.Cardio, &result.Peak); err != nil {
+		return nil, err
+	}
+
+	return result, nil
+}
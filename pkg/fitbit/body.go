@@ -0,0 +1,185 @@
+package fitbit
+
+import (
+	"database/sql"
+	"fmt"
+	"time"
+)
+
+const (
+	weightLogPath  = "/user/%s/body/log/weight/date"
+	bodyFatLogPath = "/user/%s/body/log/fat/date"
+)
+
+type BodyOptions struct {
+	StartDate *time.Time
+	EndDate   *time.Time
+}
+
+type WeightData struct {
+	Logs []WeightLog `json:"weight"`
+}
+
+type WeightLog struct {
+	LogID  int64   `json:"logId"`
+	Date   string  `json:"date"`
+	Time   string  `json:"time"`
+	Weight float64 `json:"weight"`
+	BMI    float64 `json:"bmi"`
+	Fat    float64 `json:"fat,omitempty"`
+	// Source is where the log came from such as Aria for a scale or Web and API for manual entries
+	Source string `json:"source"`
+}
+
+type BodyFatData struct {
+	Logs []BodyFatLog `json:"fat"`
+}
+
+type BodyFatLog struct {
+	LogID  int64   `json:"logId"`
+	Date   string  `json:"date"`
+	Time   string  `json:"time"`
+	Fat    float64 `json:"fat"`
+	Source string  `json:"source"`
+}
+
+func (c *Client) GetWeightLogs(user string, opts BodyOptions) (*WeightData, error) {
+	path, err := opts.toPath(weightLogPath, user)
+	if err != nil {
+		return nil, err
+	}
+
+	userClient, err := c.GetUser(user)
+	if err != nil {
+		return nil, err
+	}
+
+	data := &WeightData{}
+	if err := c.get(userClient.httpClient, path, data); err != nil {
+		return nil, err
+	}
+
+	return data, nil
+}
+
+func (c *Client) GetBodyFatLogs(user string, opts BodyOptions) (*BodyFatData, error) {
+	path, err := opts.toPath(bodyFatLogPath, user)
+	if err != nil {
+		return nil, err
+	}
+
+	userClient, err := c.GetUser(user)
+	if err != nil {
+		return nil, err
+	}
+
+	data := &BodyFatData{}
+	if err := c.get(userClient.httpClient, path, data); err != nil {
+		return nil, err
+	}
+
+	return data, nil
+}
+
+func (o BodyOptions) toPath(resourcePath, user string) (string, error) {
+	path := basePath + fmt.Sprintf(resourcePath, user)
+
+	if o.StartDate == nil {
+		path += "/today"
+	} else {
+		path += "/" + o.StartDate.Format("2006-01-02")
+	}
+
+	// Body log ranges are limited to 31 days by the api
+	if o.EndDate != nil {
+		if o.StartDate == nil {
+			return "", fmt.Errorf("body log end date given without a start date")
+		}
+		if o.EndDate.Sub(*o.StartDate) > 31*24*time.Hour {
+			return "", fmt.Errorf("body log date range can not exceed 31 days")
+		}
+		path += "/" + o.EndDate.Format("2006-01-02")
+	}
+
+	path += ".json"
+
+	return path, nil
+}
+
+func (u *User) SaveWeightData(db *sql.DB, data *WeightData) error {
+	// Logs can be edited after they are first saved so always replace them
+	for _, log := range data.Logs {
+		_, err := db.Exec(
+			`insert into weight_log (log_id, user_id, date, weight, bmi, fat, source) values (?, ?, ?, ?, ?, ?, ?)
+			on duplicate key update date = values(date), weight = values(weight), bmi = values(bmi), fat = values(fat), source = values(source)`,
+			log.LogID,
+			u.ID,
+			log.Date+" "+log.Time,
+			log.Weight,
+			log.BMI,
+			log.Fat,
+			log.Source,
+		)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (u *User) SaveBodyFatData(db *sql.DB, data *BodyFatData) error {
+	for _, log := range data.Logs {
+		_, err := db.Exec(
+			`insert into body_fat_log (log_id, user_id, date, fat, source) values (?, ?, ?, ?, ?)
+			on duplicate key update date = values(date), fat = values(fat), source = values(source)`,
+			log.LogID,
+			u.ID,
+			log.Date+" "+log.Time,
+			log.Fat,
+			log.Source,
+		)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (c *Client) GetWeightByDate(user string, startDate, endDate time.Time) ([]WeightLog, error) {
+	query := `select
+		log_id,
+		date_format(date, '%Y-%m-%d'),
+		date_format(date, '%H:%i:%s'),
+		weight,
+		bmi,
+		fat,
+		source
+	from weight_log
+	where
+		user_id = ?
+	and date between ? and ?
+	order by date`
+
+	rows, err := c.db.GetDB().Query(
+		query,
+		user,
+		startDate.Format("2006-01-02")+" 00:00:00",
+		endDate.Format("2006-01-02")+" 23:59:59",
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	results := make([]WeightLog, 0)
+	for rows.Next() {
+		var log WeightLog
+		if err := rows.Scan(&log.LogID, &log.Date, &log.Time, &log.Weight, &log.BMI, &log.Fat, &log.Source); err != nil {
+			return nil, err
+		}
+		results = append(results, log)
+	}
+	return results, nil
+}
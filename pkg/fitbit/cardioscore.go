@@ -0,0 +1,152 @@
+package fitbit
+
+import (
+	"database/sql"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	cardioScorePath = "/user/%s/cardioscore/date"
+
+	// CardioScoreMaxRange is the largest number of days that can be requested at once
+	CardioScoreMaxRange = 30 * 24 * time.Hour
+)
+
+type CardioScoreOptions struct {
+	StartDate *time.Time
+	EndDate   *time.Time
+}
+
+type CardioScoreData struct {
+	OverviewByDay []CardioScoreOverView `json:"cardioScore"`
+}
+
+type CardioScoreOverView struct {
+	Date  string           `json:"dateTime"`
+	Value CardioScoreValue `json:"value"`
+}
+
+type CardioScoreValue struct {
+	// VO2Max is either a single value such as "44" or a range such as "42-46"
+	VO2Max string `json:"vo2Max"`
+}
+
+// CardioScore is a stored vo2 max estimate where low and high are equal when an exact value was given
+type CardioScore struct {
+	Date string  `json:"date"`
+	Low  float64 `json:"low"`
+	High float64 `json:"high"`
+}
+
+// Bounds returns the lower and upper values of the vo2 max estimate
+func (v CardioScoreValue) Bounds() (float64, float64, error) {
+	parts := strings.SplitN(v.VO2Max, "-", 2)
+
+	low, err := strconv.ParseFloat(strings.TrimSpace(parts[0]), 64)
+	if err != nil {
+		return 0, 0, err
+	}
+	if len(parts) == 1 {
+		return low, low, nil
+	}
+
+	high, err := strconv.ParseFloat(strings.TrimSpace(parts[1]), 64)
+	if err != nil {
+		return 0, 0, err
+	}
+	return low, high, nil
+}
+
+func (c *Client) GetCardioScore(user string, opts CardioScoreOptions) (*CardioScoreData, error) {
+	path, err := opts.toPath(user)
+	if err != nil {
+		return nil, err
+	}
+
+	userClient, err := c.GetUser(user)
+	if err != nil {
+		return nil, err
+	}
+
+	data := &CardioScoreData{}
+	if err := c.get(userClient.httpClient, path, data); err != nil {
+		return nil, err
+	}
+
+	return data, nil
+}
+
+func (o CardioScoreOptions) toPath(user string) (string, error) {
+	path := basePath + fmt.Sprintf(cardioScorePath, user)
+
+	if o.StartDate == nil {
+		path += "/today"
+	} else {
+		path += "/" + o.StartDate.Format("2006-01-02")
+	}
+
+	if o.EndDate != nil {
+		if o.StartDate == nil {
+			return "", fmt.Errorf("cardio score end date given without a start date")
+		}
+		if o.EndDate.Sub(*o.StartDate) > CardioScoreMaxRange {
+			return "", fmt.Errorf("cardio score date range can not exceed 30 days")
+		}
+		path += "/" + o.EndDate.Format("2006-01-02")
+	}
+
+	path += ".json"
+
+	return path, nil
+}
+
+func (u *User) SaveCardioScoreData(db *sql.DB, data *CardioScoreData) error {
+	for _, day := range data.OverviewByDay {
+		if day.Value.VO2Max == "" {
+			continue
+		}
+
+		low, high, err := day.Value.Bounds()
+		if err != nil {
+			return err
+		}
+
+		if _, err := db.Exec(
+			`insert into cardio_score (user_id, date, vo2_max_low, vo2_max_high) values (?, ?, ?, ?)
+			on duplicate key update vo2_max_low = values(vo2_max_low), vo2_max_high = values(vo2_max_high)`,
+			u.ID,
+			day.Date,
+			low,
+			high,
+		); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// GetVO2Max returns the best or worst cardio score depending on top
+func (c *Client) GetVO2Max(user string, top bool) (*CardioScore, error) {
+	order := "vo2_max_high DESC, vo2_max_low DESC"
+	if !top {
+		order = "vo2_max_low ASC, vo2_max_high ASC"
+	}
+
+	result := &CardioScore{}
+	query := fmt.Sprintf(
+		"select date_format(date, '%%Y-%%m-%%d'), vo2_max_low, vo2_max_high from cardio_score where user_id = ? order by %s limit 1",
+		order,
+	)
+	if err := c.db.GetDB().QueryRow(query, user).Scan(&result.Date, &result.Low, &result.High); err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	return result, nil
+}
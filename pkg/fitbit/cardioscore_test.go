@@ -0,0 +1,33 @@
+package fitbit
+
+import "testing"
+
+func TestCardioScoreBounds(t *testing.T) {
+	tests := []struct {
+		vo2Max   string
+		wantLow  float64
+		wantHigh float64
+		wantErr  bool
+	}{
+		{"42-46", 42, 46, false},
+		{"44", 44, 44, false},
+		{"44.5", 44.5, 44.5, false},
+		{" 42 - 46 ", 42, 46, false},
+		{"", 0, 0, true},
+		{"high", 0, 0, true},
+		{"42-", 0, 0, true},
+		{"-46", 0, 0, true},
+	}
+
+	for _, test := range tests {
+		t.Run(test.vo2Max, func(t *testing.T) {
+			low, high, err := CardioScoreValue{VO2Max: test.vo2Max}.Bounds()
+			if (err != nil) != test.wantErr {
+				t.Fatalf("Bounds() error = %v, want error %t", err, test.wantErr)
+			}
+			if low != test.wantLow || high != test.wantHigh {
+				t.Errorf("Bounds() = %v, %v, want %v, %v", low, high, test.wantLow, test.wantHigh)
+			}
+		})
+	}
+}
@@ -4,32 +4,52 @@ import (
 	"encoding/json"
 	"fmt"
 	"io/ioutil"
+	"log"
 	"net/http"
 	"strconv"
 	"strings"
+	"sync"
 	"time"
 
+	"github.com/bah2830/fitbit-exporter/pkg/config"
 	"github.com/bah2830/fitbit-exporter/pkg/database"
 	"golang.org/x/oauth2"
 	"golang.org/x/oauth2/fitbit"
 )
 
 const (
-	callbackURL = "http://localhost:3000/callback"
-	listenURL   = "http://localhost:3000/"
 	basePath    = "https://api.fitbit.com/1"
+	basePathV12 = "https://api.fitbit.com/1.2"
+
+	// Fractional seconds are optional when parsing so this matches timestamps with and without milliseconds
+	fitbitDateTimeFormat = "2006-01-02T15:04:05"
 )
 
 var requiredScopes = []string{
 	"profile",
+	"activity",
 	"heartrate",
+	"sleep",
+	"oxygen_saturation",
+	"respiratory_rate",
+	"temperature",
+	"weight",
+	"location",
+	"settings",
+	"cardio_fitness",
+	"nutrition",
+	"electrocardiogram",
+	"irregular_rhythm_notifications",
 }
 
 type Client struct {
-	Users        []*User
+	users        []*User
+	usersMu      sync.RWMutex
 	oauthConfig  *oauth2.Config
 	clientID     string
 	clientSecret string
+	sessionKey   []byte
+	cfg          *config.Config
 	db           *database.Database
 }
 
@@ -55,13 +75,26 @@ func (e *RequestError) string() string {
 	return strings.TrimSpace(msg)
 }
 
-func NewClient(db *database.Database, clientID, clientSecret string) (*Client, error) {
+func NewClient(db *database.Database, cfg *config.Config) (*Client, error) {
+	sessionKey := []byte(cfg.WebFrontend.SessionKey)
+	if len(sessionKey) == 0 {
+		// Logins started before a restart will fail without a configured key
+		log.Print("no session key configured, generating a random one")
+		key, err := randomString(32)
+		if err != nil {
+			return nil, err
+		}
+		sessionKey = []byte(key)
+	}
+
 	client := &Client{
-		clientID:     clientID,
-		clientSecret: clientSecret,
+		clientID:     cfg.Fitbit.ClientID,
+		clientSecret: cfg.Fitbit.ClientSecret,
+		sessionKey:   sessionKey,
+		cfg:          cfg,
 		db:           db,
-		Users:        make([]*User, 0),
-		oauthConfig:  defaultOauthConfig(clientID, clientSecret),
+		users:        make([]*User, 0),
+		oauthConfig:  defaultOauthConfig(cfg.Fitbit.ClientID, cfg.Fitbit.ClientSecret, cfg.PublicURL("callback")),
 	}
 
 	// Set a wait on the authenticated check
@@ -72,7 +105,7 @@ func NewClient(db *database.Database, clientID, clientSecret string) (*Client, e
 	return client, nil
 }
 
-func defaultOauthConfig(clientID, clientSecret string) *oauth2.Config {
+func defaultOauthConfig(clientID, clientSecret, callbackURL string) *oauth2.Config {
 	return &oauth2.Config{
 		ClientID:     clientID,
 		ClientSecret: clientSecret,
@@ -82,8 +115,38 @@ func defaultOauthConfig(clientID, clientSecret string) *oauth2.Config {
 	}
 }
 
+// GetUsers returns a copy of the list of authenticated users
+func (c *Client) GetUsers() []*User {
+	c.usersMu.RLock()
+	defer c.usersMu.RUnlock()
+
+	users := make([]*User, len(c.users))
+	copy(users, c.users)
+	return users
+}
+
+// addUser adds the user replacing any existing entry for them such as when they log in again
+func (c *Client) addUser(user *User) {
+	c.usersMu.Lock()
+	defer c.usersMu.Unlock()
+
+	for i, u := range c.users {
+		if u.ID == user.ID {
+			c.users[i] = user
+			if u.httpClient != nil {
+				u.httpClient.CloseIdleConnections()
+			}
+			return
+		}
+	}
+	c.users = append(c.users, user)
+}
+
 func (c *Client) GetUser(userID string) (*User, error) {
-	for _, u := range c.Users {
+	c.usersMu.RLock()
+	defer c.usersMu.RUnlock()
+
+	for _, u := range c.users {
 		if u.ID == userID {
 			return u, nil
 		}
@@ -98,51 +161,15 @@ func (c *Client) setupAuth() error {
 		return err
 	}
 
-	c.Users = users
+	c.usersMu.Lock()
+	c.users = users
+	c.usersMu.Unlock()
 	return nil
 }
 
-func (c *Client) LoginHandler(w http.ResponseWriter, r *http.Request) {
-	url := c.oauthConfig.AuthCodeURL("")
-	http.Redirect(w, r, url, http.StatusTemporaryRedirect)
-}
-
-func (c *Client) CallbackHandler(w http.ResponseWriter, r *http.Request) {
-	code := r.FormValue("code")
-
-	token, err := c.oauthConfig.Exchange(oauth2.NoContext, code)
-	if err != nil {
-		// If err getting a token then redirect to the root path to get a valid login token
-		http.Redirect(w, r, listenURL, http.StatusTemporaryRedirect)
-		return
-	}
-
-	httpClient := c.oauthConfig.Client(oauth2.NoContext, token)
-	user, err := c.GetCurrentUser(httpClient)
-	if err != nil {
-		w.Write([]byte(err.Error()))
-		return
-	}
-
-	user.token = token
-	user.httpClient = httpClient
-	c.Users = append(c.Users, user)
-
-	if err := user.save(c.db.GetDB()); err != nil {
-		w.Write([]byte(err.Error()))
-		return
-	}
-	if err := user.saveToken(c.db.GetDB()); err != nil {
-		w.Write([]byte(err.Error()))
-		return
-	}
-
-	http.Redirect(w, r, "/"+user.ID, http.StatusTemporaryRedirect)
-}
-
 func (c *Client) saveTokens() error {
-	for _, user := range c.Users {
-		if err := user.saveToken(c.db.GetDB()); err != nil {
+	for _, user := range c.GetUsers() {
+		if err := user.updateToken(c.db.GetDB()); err != nil {
 			return err
 		}
 	}
@@ -151,43 +178,55 @@ func (c *Client) saveTokens() error {
 
 func (c *Client) Close() error {
 	defer func() {
-		for _, u := range c.Users {
+		for _, u := range c.GetUsers() {
 			u.httpClient.CloseIdleConnections()
 		}
 	}()
 
-	for _, user := range c.Users {
-		// Get the current token and save it. This will help prevent a refreshed token from being missed
-		oauthTransport, ok := user.httpClient.Transport.(*oauth2.Transport)
-		if !ok {
-			return nil
-		}
-
-		token, err := oauthTransport.Source.Token()
-		if err != nil {
-			return err
-		}
-
-		user.token = token
-	}
+	// Tokens are saved as soon as they are refreshed but save them once more in case one of those writes failed
 	return c.saveTokens()
 }
 
 func (c *Client) get(client *http.Client, path string, output interface{}) error {
-	resp, err := client.Get(path)
+	b, err := c.getRaw(client, path)
 	if err != nil {
 		return err
 	}
 
+	if err := json.Unmarshal(b, output); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// getRaw returns the unparsed response body for endpoints that don't respond with json
+func (c *Client) getRaw(client *http.Client, path string) ([]byte, error) {
+	req, err := http.NewRequest(http.MethodGet, path, nil)
+	if err != nil {
+		return nil, err
+	}
+	return c.do(client, req)
+}
+
+// do sends the request and returns the response body or a RequestError for unsuccessful responses
+func (c *Client) do(client *http.Client, req *http.Request) ([]byte, error) {
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	recordAPICall(resp.StatusCode)
+
 	b, err := ioutil.ReadAll(resp.Body)
 	if err != nil {
-		return err
+		return nil, err
 	}
 
 	if resp.StatusCode > 299 {
 		errData := &RequestError{}
 		if err := json.Unmarshal(b, errData); err != nil {
-			return err
+			return nil, err
 		}
 		errData.Code = resp.StatusCode
 
@@ -195,17 +234,13 @@ func (c *Client) get(client *http.Client, path string, output interface{}) error
 		if retryAfterStr != "" {
 			retryAfter, err := strconv.Atoi(retryAfterStr)
 			if err != nil {
-				return err
+				return nil, err
 			}
 			errData.RetryAfter = time.Duration(retryAfter+30) * time.Second
 		}
 
-		return errData
+		return nil, errData
 	}
 
-	if err := json.Unmarshal(b, output); err != nil {
-		return err
-	}
-
-	return nil
+	return b, nil
 }
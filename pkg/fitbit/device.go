@@ -0,0 +1,112 @@
+package fitbit
+
+import (
+	"database/sql"
+	"fmt"
+)
+
+const devicesPath = "/user/%s/devices.json"
+
+// Device is a tracker or scale paired with the account. The devices endpoint does not report a firmware version,
+// deviceVersion is the model name, so there is no firmware to store for a device.
+type Device struct {
+	ID            string `json:"id"`
+	Type          string `json:"type"`
+	DeviceVersion string `json:"deviceVersion"`
+	Battery       string `json:"battery"`
+	BatteryLevel  int    `json:"batteryLevel"`
+	LastSyncTime  string `json:"lastSyncTime"`
+	Mac           string `json:"mac"`
+}
+
+func (c *Client) GetDevices(user string) ([]Device, error) {
+	userClient, err := c.GetUser(user)
+	if err != nil {
+		return nil, err
+	}
+
+	data := make([]Device, 0)
+	if err := c.get(userClient.httpClient, basePath+fmt.Sprintf(devicesPath, user), &data); err != nil {
+		return nil, err
+	}
+
+	return data, nil
+}
+
+// SaveDevices replaces the stored devices for the user so unpaired trackers are removed
+func (u *User) SaveDevices(db *sql.DB, devices []Device) error {
+	tx, err := db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.Exec("delete from device where user_id = ?", u.ID); err != nil {
+		return err
+	}
+
+	insertStatement := `insert into device
+	(id, user_id, type, device_version, battery, battery_level, last_sync_time, mac)
+	values (?, ?, ?, ?, ?, ?, ?, ?)`
+
+	for _, device := range devices {
+		lastSync, err := formatFitbitDateTime(device.LastSyncTime)
+		if err != nil {
+			return err
+		}
+
+		if _, err := tx.Exec(
+			insertStatement,
+			device.ID,
+			u.ID,
+			device.Type,
+			device.DeviceVersion,
+			device.Battery,
+			device.BatteryLevel,
+			lastSync,
+			device.Mac,
+		); err != nil {
+			return err
+		}
+	}
+
+	return tx.Commit()
+}
+
+func (c *Client) GetStoredDevices(user string) ([]Device, error) {
+	query := `select
+		id,
+		type,
+		device_version,
+		battery,
+		battery_level,
+		last_sync_time,
+		mac
+	from device
+	where user_id = ?
+	order by last_sync_time desc`
+
+	rows, err := c.db.GetDB().Query(query, user)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	results := make([]Device, 0)
+	for rows.Next() {
+		var device Device
+		if err := rows.Scan(
+			&device.ID,
+			&device.Type,
+			&device.DeviceVersion,
+			&device.Battery,
+			&device.BatteryLevel,
+			&device.LastSyncTime,
+			&device.Mac,
+		); err != nil {
+			return nil, err
+		}
+		results = append(results, device)
+	}
+	return results, nil
+}
@@ -0,0 +1,140 @@
+package fitbit
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"time"
+)
+
+const (
+	ecgListPath = "/user/%s/ecg/list.json"
+
+	// ecgPageLimit is the largest page size allowed by the api
+	ecgPageLimit = 10
+)
+
+type ECGReadingList struct {
+	Readings   []ECGReading `json:"ecgReadings"`
+	Pagination *Pagination  `json:"pagination"`
+}
+
+type ECGReading struct {
+	StartTime               string `json:"startTime"`
+	AverageHeartRate        int    `json:"averageHeartRate"`
+	ResultClassification    string `json:"resultClassification"`
+	SamplingFrequencyHz     string `json:"samplingFrequencyHz"`
+	ScalingFactor           int    `json:"scalingFactor"`
+	NumberOfWaveformSamples int    `json:"numberOfWaveformSamples"`
+	LeadNumber              int    `json:"leadNumber"`
+	FeatureVersion          string `json:"featureVersion"`
+	DeviceName              string `json:"deviceName"`
+	FirmwareVersion         string `json:"firmwareVersion"`
+	WaveformSamples         []int  `json:"waveformSamples,omitempty"`
+}
+
+func (c *Client) GetECGReadings(user string, opts ListOptions) (*ECGReadingList, error) {
+	path, err := opts.toPath(fmt.Sprintf(ecgListPath, user), ecgPageLimit)
+	if err != nil {
+		return nil, err
+	}
+
+	return c.GetECGReadingsPage(user, path)
+}
+
+// GetECGReadingsPage gets a page of ecg readings from a pagination link of a previous response
+func (c *Client) GetECGReadingsPage(user string, link string) (*ECGReadingList, error) {
+	userClient, err := c.GetUser(user)
+	if err != nil {
+		return nil, err
+	}
+
+	data := &ECGReadingList{}
+	if err := c.get(userClient.httpClient, link, data); err != nil {
+		return nil, err
+	}
+
+	return data, nil
+}
+
+func (u *User) SaveECGReadings(db *sql.DB, readings []ECGReading) error {
+	for _, reading := range readings {
+		startTime, err := formatFitbitDateTime(reading.StartTime)
+		if err != nil {
+			return err
+		}
+
+		var count int
+		if err := db.QueryRow("select count(*) from ecg_reading where user_id = ? and start_time = ?", u.ID, startTime).Scan(&count); err != nil {
+			return err
+		}
+		if count > 0 {
+			continue
+		}
+
+		// The waveform is only ever read back as a whole so it is kept as a single json encoded blob
+		waveform, err := json.Marshal(reading.WaveformSamples)
+		if err != nil {
+			return err
+		}
+
+		insertStatement := `insert into ecg_reading
+		(user_id, start_time, average_heart_rate, classification, sampling_frequency, scaling_factor,
+		number_of_samples, lead_number, feature_version, device_name, firmware_version, waveform)
+		values (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`
+
+		if _, err := db.Exec(
+			insertStatement,
+			u.ID,
+			startTime,
+			reading.AverageHeartRate,
+			reading.ResultClassification,
+			reading.SamplingFrequencyHz,
+			reading.ScalingFactor,
+			reading.NumberOfWaveformSamples,
+			reading.LeadNumber,
+			reading.FeatureVersion,
+			reading.DeviceName,
+			reading.FirmwareVersion,
+			waveform,
+		); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// GetECGRange returns the start times of the oldest and newest saved readings
+func (c *Client) GetECGRange(user string) (*time.Time, *time.Time, error) {
+	return c.getSavedRange("ecg_reading", "start_time", user)
+}
+
+// GetLatestECGReadings returns the most recent readings without their waveforms
+func (c *Client) GetLatestECGReadings(user string, limit int) ([]ECGReading, error) {
+	query := `select
+		start_time,
+		average_heart_rate,
+		classification,
+		device_name
+	from ecg_reading
+	where user_id = ?
+	order by start_time desc
+	limit ?`
+
+	rows, err := c.db.GetDB().Query(query, user, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	results := make([]ECGReading, 0, limit)
+	for rows.Next() {
+		var reading ECGReading
+		if err := rows.Scan(&reading.StartTime, &reading.AverageHeartRate, &reading.ResultClassification, &reading.DeviceName); err != nil {
+			return nil, err
+		}
+		results = append(results, reading)
+	}
+	return results, nil
+}
@@ -11,6 +11,9 @@ const (
 
 	HeartRateDetailLevel1Sec HeartRateDetailLevel = "1sec"
 	HeartRateDetailLevel1Min HeartRateDetailLevel = "1min"
+
+	// HeartRateMaxRange is the largest number of days of summaries that can be requested at once
+	HeartRateMaxRange = 365 * 24 * time.Hour
 )
 
 type HeartRateDetailLevel string
@@ -20,6 +23,10 @@ type HeartRateOptions struct {
 	StartDate   *time.Time
 	EndDate     *time.Time
 	DetailLevel *HeartRateDetailLevel
+	// StartTime and EndTime limit intraday data to a window of the start date. Only the time of day is used
+	// and the end time defaults to the end of the day.
+	StartTime *time.Time
+	EndTime   *time.Time
 }
 
 type HeartRateData struct {
@@ -79,6 +86,10 @@ func (c *Client) GetHeartData(user string, opts HeartRateOptions) (*HeartRateDat
 func (o HeartRateOptions) toPath(user string) (string, error) {
 	path := basePath + fmt.Sprintf(heartRatePath, user)
 
+	if o.StartTime != nil || o.EndTime != nil {
+		return o.toTimePath(path)
+	}
+
 	if o.StartDate == nil {
 		path += "/today"
 	} else {
@@ -93,6 +104,12 @@ func (o HeartRateOptions) toPath(user string) (string, error) {
 			path += "/" + o.StartDate.Add(24*time.Hour).Format("2006-01-02")
 		}
 	} else {
+		if o.StartDate == nil {
+			return "", fmt.Errorf("heart rate end date given without a start date")
+		}
+		if o.EndDate.Sub(*o.StartDate) > HeartRateMaxRange {
+			return "", fmt.Errorf("heart rate date range can not exceed 1 year")
+		}
 		path += "/" + o.EndDate.Format("2006-01-02")
 	}
 
@@ -105,6 +122,38 @@ func (o HeartRateOptions) toPath(user string) (string, error) {
 	return path, nil
 }
 
+// toTimePath builds the intraday path for a time window within a single day
+func (o HeartRateOptions) toTimePath(path string) (string, error) {
+	if o.DetailLevel == nil {
+		return "", fmt.Errorf("detail level is required when requesting a time window")
+	}
+	if o.EndDate != nil && (o.StartDate == nil || o.EndDate.Format("2006-01-02") != o.StartDate.Format("2006-01-02")) {
+		return "", fmt.Errorf("time windows can only be requested within a single day")
+	}
+
+	if o.StartDate == nil {
+		path += "/today"
+	} else {
+		path += "/" + o.StartDate.Format("2006-01-02")
+	}
+
+	startTime := "00:00"
+	if o.StartTime != nil {
+		startTime = o.StartTime.Format("15:04")
+	}
+	endTime := "23:59"
+	if o.EndTime != nil {
+		endTime = o.EndTime.Format("15:04")
+	}
+	if startTime > endTime {
+		return "", fmt.Errorf("start time %s is after end time %s", startTime, endTime)
+	}
+
+	path += "/1d/" + string(*o.DetailLevel) + "/time/" + startTime + "/" + endTime + ".json"
+
+	return path, nil
+}
+
 func GetHeartRateDetailLevel(level HeartRateDetailLevel) *HeartRateDetailLevel {
 	return &level
 }
@@ -181,6 +230,11 @@ func (c *Client) GetResting(user string, top bool) (*HeartData, error) {
 	}, nil
 }
 
+// GetRestingRange returns the oldest and newest days with a saved resting heart rate
+func (c *Client) GetRestingRange(user string) (*time.Time, *time.Time, error) {
+	return c.getSavedRange("heart_rest", "date", user)
+}
+
 func (c *Client) GetCurrentResting(user string) (int, error) {
 	var value int
 	query := "select value from heart_rest where user_id = ? and date_format(date, '%Y-%m-%d') = date_format(now(), '%Y-%m-%d')"
@@ -217,6 +271,23 @@ func (c *Client) GetCurrentDaysData(user string) ([]HeartData, error) {
 	return results, nil
 }
 
+func (c *Client) GetLatestHeartRate(user string) (*HeartData, error) {
+	var date string
+	var value int
+	query := "select date, value from heart_data where user_id = ? order by date desc limit 1"
+	if err := c.db.GetDB().QueryRow(query, user).Scan(&date, &value); err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	return &HeartData{
+		Time:  date,
+		Value: value,
+	}, nil
+}
+
 func (c *Client) GetCurrentDayLimit(user string, top bool) (*HeartData, error) {
 	order := "DESC"
 	if !top {
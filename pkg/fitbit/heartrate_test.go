@@ -0,0 +1,70 @@
+package fitbit
+
+import (
+	"testing"
+	"time"
+)
+
+func TestHeartRateTimePath(t *testing.T) {
+	day := time.Date(2026, 1, 2, 0, 0, 0, 0, time.Local)
+	nextDay := day.AddDate(0, 0, 1)
+	start := time.Date(2026, 1, 2, 8, 5, 30, 0, time.Local)
+	end := time.Date(2026, 1, 2, 17, 45, 0, 0, time.Local)
+	minute := GetHeartRateDetailLevel(HeartRateDetailLevel1Min)
+	base := basePath + "/user/ABC123/activities/heart/date"
+
+	tests := []struct {
+		name    string
+		opts    HeartRateOptions
+		want    string
+		wantErr bool
+	}{
+		{
+			name: "start time to the end of the day",
+			opts: HeartRateOptions{StartDate: &day, StartTime: &start, DetailLevel: minute},
+			want: base + "/2026-01-02/1d/1min/time/08:05/23:59.json",
+		},
+		{
+			name: "start of the day to end time",
+			opts: HeartRateOptions{StartDate: &day, EndDate: &day, EndTime: &end, DetailLevel: minute},
+			want: base + "/2026-01-02/1d/1min/time/00:00/17:45.json",
+		},
+		{
+			name: "window for today",
+			opts: HeartRateOptions{StartTime: &start, EndTime: &end, DetailLevel: GetHeartRateDetailLevel(HeartRateDetailLevel1Sec)},
+			want: base + "/today/1d/1sec/time/08:05/17:45.json",
+		},
+		{
+			name:    "missing detail level",
+			opts:    HeartRateOptions{StartDate: &day, StartTime: &start},
+			wantErr: true,
+		},
+		{
+			name:    "window across days",
+			opts:    HeartRateOptions{StartDate: &day, EndDate: &nextDay, StartTime: &start, DetailLevel: minute},
+			wantErr: true,
+		},
+		{
+			name:    "end date without a start date",
+			opts:    HeartRateOptions{EndDate: &day, StartTime: &start, DetailLevel: minute},
+			wantErr: true,
+		},
+		{
+			name:    "start after end",
+			opts:    HeartRateOptions{StartDate: &day, StartTime: &end, EndTime: &start, DetailLevel: minute},
+			wantErr: true,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			got, err := test.opts.toPath("ABC123")
+			if (err != nil) != test.wantErr {
+				t.Fatalf("toPath() error = %v, want error %t", err, test.wantErr)
+			}
+			if got != test.want {
+				t.Errorf("toPath() = %q, want %q", got, test.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,145 @@
+package fitbit
+
+import (
+	"database/sql"
+	"fmt"
+	"strings"
+	"time"
+)
+
+const hrvPath = "/user/%s/hrv/date"
+
+type HRVOptions struct {
+	StartDate *time.Time
+	EndDate   *time.Time
+	// Intraday requests the 5 minute samples recorded during sleep rather than the daily summary
+	Intraday bool
+}
+
+type HRVData struct {
+	Days []HRVDay `json:"hrv"`
+}
+
+type HRVDay struct {
+	Date    string      `json:"dateTime"`
+	Value   HRVValue    `json:"value"`
+	Minutes []HRVMinute `json:"minutes"`
+}
+
+type HRVValue struct {
+	DailyRmssd float64 `json:"dailyRmssd"`
+	DeepRmssd  float64 `json:"deepRmssd"`
+}
+
+type HRVMinute struct {
+	Minute string         `json:"minute"`
+	Value  HRVMinuteValue `json:"value"`
+}
+
+type HRVMinuteValue struct {
+	Rmssd    float64 `json:"rmssd"`
+	Coverage float64 `json:"coverage"`
+	HF       float64 `json:"hf"`
+	LF       float64 `json:"lf"`
+}
+
+func (c *Client) GetHRV(user string, opts HRVOptions) (*HRVData, error) {
+	path, err := opts.toPath(user)
+	if err != nil {
+		return nil, err
+	}
+
+	userClient, err := c.GetUser(user)
+	if err != nil {
+		return nil, err
+	}
+
+	data := &HRVData{}
+	if err := c.get(userClient.httpClient, path, data); err != nil {
+		return nil, err
+	}
+
+	return data, nil
+}
+
+func (o HRVOptions) toPath(user string) (string, error) {
+	path := basePath + fmt.Sprintf(hrvPath, user)
+
+	if o.StartDate == nil {
+		path += "/today"
+	} else {
+		path += "/" + o.StartDate.Format("2006-01-02")
+	}
+
+	// HRV ranges are limited to 30 days by the api
+	if o.EndDate != nil {
+		if o.StartDate == nil {
+			return "", fmt.Errorf("hrv end date given without a start date")
+		}
+		if o.EndDate.Sub(*o.StartDate) > 30*24*time.Hour {
+			return "", fmt.Errorf("hrv date range can not exceed 30 days")
+		}
+		path += "/" + o.EndDate.Format("2006-01-02")
+	}
+
+	if o.Intraday {
+		path += "/all"
+	}
+
+	path += ".json"
+
+	return path, nil
+}
+
+func (u *User) SaveHRVData(db *sql.DB, data *HRVData) error {
+	for _, day := range data.Days {
+		if day.Value.DailyRmssd != 0 {
+			_, err := db.Exec(
+				`insert into hrv_daily (user_id, date, daily_rmssd, deep_rmssd) values (?, ?, ?, ?)
+				on duplicate key update daily_rmssd = values(daily_rmssd), deep_rmssd = values(deep_rmssd)`,
+				u.ID,
+				day.Date,
+				day.Value.DailyRmssd,
+				day.Value.DeepRmssd,
+			)
+			if err != nil {
+				return err
+			}
+		}
+
+		if err := u.saveHRVIntraday(db, day); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (u *User) saveHRVIntraday(db *sql.DB, day HRVDay) error {
+	if len(day.Minutes) == 0 {
+		return nil
+	}
+
+	values := make([]string, 0, len(day.Minutes))
+	for _, m := range day.Minutes {
+		date, err := formatFitbitDateTime(m.Minute)
+		if err != nil {
+			return err
+		}
+		values = append(values, fmt.Sprintf(
+			"('%s', '%s', %f, %f, %f, %f)",
+			u.ID,
+			date,
+			m.Value.Rmssd,
+			m.Value.Coverage,
+			m.Value.HF,
+			m.Value.LF,
+		))
+	}
+
+	// A single night is at most a few hundred samples so they can be inserted at once.
+	// Samples are reprocessed after the night so replace any saved before.
+	_, err := db.Exec("insert into hrv_intraday (user_id, date, rmssd, coverage, hf, lf) values " + strings.Join(values, ", ") +
+		" on duplicate key update rmssd = values(rmssd), coverage = values(coverage), hf = values(hf), lf = values(lf)")
+	return err
+}
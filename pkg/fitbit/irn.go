@@ -0,0 +1,115 @@
+package fitbit
+
+import (
+	"database/sql"
+	"fmt"
+	"time"
+)
+
+const (
+	irnAlertListPath = "/user/%s/irn/alerts/list.json"
+
+	// irnPageLimit is the largest page size allowed by the api
+	irnPageLimit = 10
+)
+
+type IRNAlertList struct {
+	Alerts     []IRNAlert  `json:"alerts"`
+	Pagination *Pagination `json:"pagination"`
+}
+
+// IRNAlert is an irregular rhythm notification sent to the user
+type IRNAlert struct {
+	AlertTime      string `json:"alertTime"`
+	DetectedTime   string `json:"detectedTime"`
+	ServiceVersion string `json:"serviceVersion"`
+}
+
+func (c *Client) GetIRNAlerts(user string, opts ListOptions) (*IRNAlertList, error) {
+	path, err := opts.toPath(fmt.Sprintf(irnAlertListPath, user), irnPageLimit)
+	if err != nil {
+		return nil, err
+	}
+
+	return c.GetIRNAlertsPage(user, path)
+}
+
+// GetIRNAlertsPage gets a page of alerts from a pagination link of a previous response
+func (c *Client) GetIRNAlertsPage(user string, link string) (*IRNAlertList, error) {
+	userClient, err := c.GetUser(user)
+	if err != nil {
+		return nil, err
+	}
+
+	data := &IRNAlertList{}
+	if err := c.get(userClient.httpClient, link, data); err != nil {
+		return nil, err
+	}
+
+	return data, nil
+}
+
+func (u *User) SaveIRNAlerts(db *sql.DB, alerts []IRNAlert) error {
+	for _, alert := range alerts {
+		alertTime, err := formatFitbitDateTime(alert.AlertTime)
+		if err != nil {
+			return err
+		}
+		detectedTime, err := formatFitbitDateTime(alert.DetectedTime)
+		if err != nil {
+			return err
+		}
+
+		var count int
+		if err := db.QueryRow("select count(*) from irn_alert where user_id = ? and alert_time = ?", u.ID, alertTime).Scan(&count); err != nil {
+			return err
+		}
+		if count > 0 {
+			continue
+		}
+
+		if _, err := db.Exec(
+			"insert into irn_alert (user_id, alert_time, detected_time, service_version) values (?, ?, ?, ?)",
+			u.ID,
+			alertTime,
+			detectedTime,
+			alert.ServiceVersion,
+		); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// GetIRNAlertRange returns the alert times of the oldest and newest saved alerts
+func (c *Client) GetIRNAlertRange(user string) (*time.Time, *time.Time, error) {
+	return c.getSavedRange("irn_alert", "alert_time", user)
+}
+
+func (c *Client) GetLatestIRNAlerts(user string, limit int) ([]IRNAlert, error) {
+	query := `select
+		alert_time,
+		detected_time,
+		service_version
+	from irn_alert
+	where user_id = ?
+	order by alert_time desc
+	limit ?`
+
+	rows, err := c.db.GetDB().Query(query, user, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	results := make([]IRNAlert, 0, limit)
+	for rows.Next() {
+		var alert IRNAlert
+		if err := rows.Scan(&alert.AlertTime, &alert.DetectedTime, &alert.ServiceVersion); err != nil {
+			return nil, err
+		}
+		results = append(results, alert)
+	}
+	return results, nil
+}
@@ -0,0 +1,218 @@
+package fitbit
+
+import (
+	"database/sql"
+	"fmt"
+	"time"
+)
+
+const (
+	lifetimeStatsPath = "/user/%s/activities.json"
+	badgesPath        = "/user/%s/badges.json"
+)
+
+type LifetimeStatsData struct {
+	Lifetime struct {
+		Total LifetimeStats `json:"total"`
+	} `json:"lifetime"`
+}
+
+type LifetimeStats struct {
+	Date        string  `json:"date,omitempty"`
+	Distance    float64 `json:"distance"`
+	Floors      float64 `json:"floors"`
+	Steps       int64   `json:"steps"`
+	CaloriesOut float64 `json:"caloriesOut"`
+}
+
+type BadgeData struct {
+	Badges []Badge `json:"badges"`
+}
+
+type Badge struct {
+	EncodedID     string `json:"encodedId"`
+	BadgeType     string `json:"badgeType"`
+	Category      string `json:"category"`
+	Name          string `json:"name"`
+	ShortName     string `json:"shortName"`
+	Description   string `json:"description"`
+	Value         int    `json:"value"`
+	TimesAchieved int    `json:"timesAchieved"`
+	// Date is the last day the badge was earned
+	Date  string `json:"dateTime"`
+	Image string `json:"image100px"`
+}
+
+func (c *Client) GetLifetimeStats(user string) (*LifetimeStatsData, error) {
+	userClient, err := c.GetUser(user)
+	if err != nil {
+		return nil, err
+	}
+
+	data := &LifetimeStatsData{}
+	if err := c.get(userClient.httpClient, basePath+fmt.Sprintf(lifetimeStatsPath, user), data); err != nil {
+		return nil, err
+	}
+
+	return data, nil
+}
+
+func (c *Client) GetBadges(user string) (*BadgeData, error) {
+	userClient, err := c.GetUser(user)
+	if err != nil {
+		return nil, err
+	}
+
+	data := &BadgeData{}
+	if err := c.get(userClient.httpClient, basePath+fmt.Sprintf(badgesPath, user), data); err != nil {
+		return nil, err
+	}
+
+	return data, nil
+}
+
+// SaveLifetimeStats stores a snapshot of the lifetime totals for the current day replacing any earlier one
+func (u *User) SaveLifetimeStats(db *sql.DB, data *LifetimeStatsData) error {
+	day := time.Now().Format("2006-01-02")
+	stats := data.Lifetime.Total
+
+	tx, err := db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.Exec("delete from lifetime_stats where user_id = ? and date = ?", u.ID, day); err != nil {
+		return err
+	}
+
+	if _, err := tx.Exec(
+		"insert into lifetime_stats (user_id, date, distance, floors, steps, calories_out) values (?, ?, ?, ?, ?, ?)",
+		u.ID,
+		day,
+		stats.Distance,
+		stats.Floors,
+		stats.Steps,
+		stats.CaloriesOut,
+	); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// SaveBadges inserts new badges and updates the earned date of ones that have been earned again
+func (u *User) SaveBadges(db *sql.DB, data *BadgeData) error {
+	for _, badge := range data.Badges {
+		var count int
+		if err := db.QueryRow("select count(*) from badge where user_id = ? and encoded_id = ?", u.ID, badge.EncodedID).Scan(&count); err != nil {
+			return err
+		}
+
+		if count > 0 {
+			if _, err := db.Exec(
+				"update badge set date = ?, times_achieved = ? where user_id = ? and encoded_id = ?",
+				badge.Date,
+				badge.TimesAchieved,
+				u.ID,
+				badge.EncodedID,
+			); err != nil {
+				return err
+			}
+			continue
+		}
+
+		insertStatement := `insert into badge
+		(user_id, encoded_id, badge_type, category, name, short_name, description, value, times_achieved, date, image)
+		values (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`
+
+		if _, err := db.Exec(
+			insertStatement,
+			u.ID,
+			badge.EncodedID,
+			badge.BadgeType,
+			badge.Category,
+			badge.Name,
+			badge.ShortName,
+			badge.Description,
+			badge.Value,
+			badge.TimesAchieved,
+			badge.Date,
+			badge.Image,
+		); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (c *Client) GetCurrentLifetimeStats(user string) (*LifetimeStats, error) {
+	query := `select
+		date_format(date, '%Y-%m-%d'),
+		distance,
+		floors,
+		steps,
+		calories_out
+	from lifetime_stats
+	where user_id = ?
+	order by date desc
+	limit 1`
+
+	stats := &LifetimeStats{}
+	if err := c.db.GetDB().QueryRow(query, user).Scan(&stats.Date, &stats.Distance, &stats.Floors, &stats.Steps, &stats.CaloriesOut); err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	return stats, nil
+}
+
+// GetRecentBadges returns badges earned on or after the given date with the newest first
+func (c *Client) GetRecentBadges(user string, since time.Time) ([]Badge, error) {
+	query := `select
+		encoded_id,
+		badge_type,
+		category,
+		name,
+		short_name,
+		description,
+		value,
+		times_achieved,
+		date_format(date, '%Y-%m-%d'),
+		image
+	from badge
+	where
+		user_id = ?
+	and date >= ?
+	order by date desc`
+
+	rows, err := c.db.GetDB().Query(query, user, since.Format("2006-01-02"))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	results := make([]Badge, 0)
+	for rows.Next() {
+		var badge Badge
+		if err := rows.Scan(
+			&badge.EncodedID,
+			&badge.BadgeType,
+			&badge.Category,
+			&badge.Name,
+			&badge.ShortName,
+			&badge.Description,
+			&badge.Value,
+			&badge.TimesAchieved,
+			&badge.Date,
+			&badge.Image,
+		); err != nil {
+			return nil, err
+		}
+		results = append(results, badge)
+	}
+	return results, nil
+}
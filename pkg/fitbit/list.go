@@ -0,0 +1,68 @@
+package fitbit
+
+import (
+	"database/sql"
+	"fmt"
+	"net/url"
+	"strconv"
+	"time"
+
+	"github.com/bah2830/fitbit-exporter/pkg/database"
+)
+
+// ListOptions are used by the paginated list endpoints and require exactly one of BeforeDate or AfterDate.
+// Entries before a date are returned newest first while entries after a date are returned oldest first.
+type ListOptions struct {
+	BeforeDate *time.Time
+	AfterDate  *time.Time
+}
+
+type Pagination struct {
+	Next     string `json:"next"`
+	Previous string `json:"previous"`
+}
+
+func (o ListOptions) toPath(path string, limit int) (string, error) {
+	params := url.Values{}
+	params.Set("offset", "0")
+	params.Set("limit", strconv.Itoa(limit))
+
+	switch {
+	case o.BeforeDate != nil && o.AfterDate != nil:
+		return "", fmt.Errorf("only one of before date or after date can be given")
+	case o.BeforeDate != nil:
+		params.Set("beforeDate", o.BeforeDate.Format(fitbitDateTimeFormat))
+		params.Set("sort", "desc")
+	case o.AfterDate != nil:
+		params.Set("afterDate", o.AfterDate.Format(fitbitDateTimeFormat))
+		params.Set("sort", "asc")
+	default:
+		return "", fmt.Errorf("one of before date or after date is required")
+	}
+
+	return basePath + path + "?" + params.Encode(), nil
+}
+
+// getSavedRange returns the oldest and newest value of a datetime column for the user
+func (c *Client) getSavedRange(table, column, user string) (*time.Time, *time.Time, error) {
+	var oldest, newest sql.NullString
+	query := fmt.Sprintf("select min(%s), max(%s) from %s where user_id = ?", column, column, table)
+	if err := c.db.GetDB().QueryRow(query, user).Scan(&oldest, &newest); err != nil {
+		return nil, nil, err
+	}
+	if !oldest.Valid || !newest.Valid {
+		return nil, nil, nil
+	}
+
+	// Dates are stored in local time without a zone
+	oldestTime, err := time.ParseInLocation(database.DateTimeFormat, oldest.String, time.Local)
+	if err != nil {
+		return nil, nil, err
+	}
+	newestTime, err := time.ParseInLocation(database.DateTimeFormat, newest.String, time.Local)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return &oldestTime, &newestTime, nil
+}
@@ -0,0 +1,91 @@
+package fitbit
+
+import (
+	"strconv"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+const (
+	// MetricsNamespace prefixes every metric, the fitbit data is exported directly under it
+	MetricsNamespace = "fitbit"
+	// MetricsSubsystem groups the metrics about the exporter itself
+	MetricsSubsystem = "exporter"
+)
+
+var (
+	apiCalls = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Namespace: MetricsNamespace,
+			Subsystem: MetricsSubsystem,
+			Name:      "api_calls_total",
+			Help:      "Number of calls made to the fitbit api by response code.",
+		},
+		[]string{"code"},
+	)
+	rateLimitLimit = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Namespace: MetricsNamespace,
+			Subsystem: MetricsSubsystem,
+			Name:      "rate_limit_calls",
+			Help:      "Number of fitbit api calls allowed per hour for the user.",
+		},
+		[]string{"user_id"},
+	)
+	rateLimitRemaining = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Namespace: MetricsNamespace,
+			Subsystem: MetricsSubsystem,
+			Name:      "rate_limit_remaining_calls",
+			Help:      "Number of fitbit api calls left for the user until the limit resets.",
+		},
+		[]string{"user_id"},
+	)
+	rateLimitReset = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Namespace: MetricsNamespace,
+			Subsystem: MetricsSubsystem,
+			Name:      "rate_limit_reset_timestamp_seconds",
+			Help:      "Time the fitbit api rate limit resets for the user.",
+		},
+		[]string{"user_id"},
+	)
+	rateLimitWait = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Namespace: MetricsNamespace,
+			Subsystem: MetricsSubsystem,
+			Name:      "rate_limit_wait_seconds_total",
+			Help:      "Time spent waiting for rate limit budget before calling the fitbit api.",
+		},
+		[]string{"user_id", "priority"},
+	)
+)
+
+func init() {
+	prometheus.MustRegister(apiCalls, rateLimitLimit, rateLimitRemaining, rateLimitReset, rateLimitWait)
+}
+
+func recordAPICall(code int) {
+	apiCalls.WithLabelValues(strconv.Itoa(code)).Inc()
+}
+
+func recordRateLimit(userID string, status RateLimitStatus) {
+	rateLimitLimit.WithLabelValues(userID).Set(float64(status.Limit))
+	rateLimitRemaining.WithLabelValues(userID).Set(float64(status.Remaining))
+	rateLimitReset.WithLabelValues(userID).Set(float64(status.Reset.Unix()))
+}
+
+func recordRateLimitWait(userID string, priority Priority, wait time.Duration) {
+	rateLimitWait.WithLabelValues(userID, priority.String()).Add(wait.Seconds())
+}
+
+// deleteUserMetrics stops reporting the rate limit for a removed user
+func deleteUserMetrics(userID string) {
+	rateLimitLimit.DeleteLabelValues(userID)
+	rateLimitRemaining.DeleteLabelValues(userID)
+	rateLimitReset.DeleteLabelValues(userID)
+	for _, priority := range []Priority{PriorityBackfill, PriorityLive} {
+		rateLimitWait.DeleteLabelValues(userID, priority.String())
+	}
+}
@@ -0,0 +1,193 @@
+package fitbit
+
+import (
+	"database/sql"
+	"fmt"
+	"time"
+)
+
+const (
+	foodLogPath  = "/user/%s/foods/log/date/%s.json"
+	waterLogPath = "/user/%s/foods/log/water/date/%s.json"
+)
+
+type FoodLogData struct {
+	Foods   []FoodLog         `json:"foods"`
+	Goals   NutritionGoals    `json:"goals"`
+	Summary NutritionalValues `json:"summary"`
+}
+
+type FoodLog struct {
+	LogID             int64             `json:"logId"`
+	LogDate           string            `json:"logDate"`
+	LoggedFood        LoggedFood        `json:"loggedFood"`
+	NutritionalValues NutritionalValues `json:"nutritionalValues"`
+}
+
+type LoggedFood struct {
+	Name       string  `json:"name"`
+	Brand      string  `json:"brand"`
+	MealTypeID int     `json:"mealTypeId"`
+	Amount     float64 `json:"amount"`
+	Unit       struct {
+		Name   string `json:"name"`
+		Plural string `json:"plural"`
+	} `json:"unit"`
+}
+
+type NutritionalValues struct {
+	Calories float64 `json:"calories"`
+	Carbs    float64 `json:"carbs"`
+	Fat      float64 `json:"fat"`
+	Fiber    float64 `json:"fiber"`
+	Protein  float64 `json:"protein"`
+	Sodium   float64 `json:"sodium"`
+	Water    float64 `json:"water"`
+}
+
+type NutritionGoals struct {
+	Calories float64 `json:"calories"`
+}
+
+type WaterLogData struct {
+	Water   []WaterLog `json:"water"`
+	Summary struct {
+		Water float64 `json:"water"`
+	} `json:"summary"`
+}
+
+type WaterLog struct {
+	LogID  int64   `json:"logId"`
+	Amount float64 `json:"amount"`
+}
+
+func (c *Client) GetFoodLogs(user string, date time.Time) (*FoodLogData, error) {
+	userClient, err := c.GetUser(user)
+	if err != nil {
+		return nil, err
+	}
+
+	data := &FoodLogData{}
+	path := basePath + fmt.Sprintf(foodLogPath, user, date.Format("2006-01-02"))
+	if err := c.get(userClient.httpClient, path, data); err != nil {
+		return nil, err
+	}
+
+	return data, nil
+}
+
+func (c *Client) GetWaterLogs(user string, date time.Time) (*WaterLogData, error) {
+	userClient, err := c.GetUser(user)
+	if err != nil {
+		return nil, err
+	}
+
+	data := &WaterLogData{}
+	path := basePath + fmt.Sprintf(waterLogPath, user, date.Format("2006-01-02"))
+	if err := c.get(userClient.httpClient, path, data); err != nil {
+		return nil, err
+	}
+
+	return data, nil
+}
+
+// SaveNutritionData replaces the individual food and water logs of the day along with the daily totals and calorie
+// goal so logs edited or deleted in the app are kept in step
+func (u *User) SaveNutritionData(db *sql.DB, date time.Time, food *FoodLogData, water *WaterLogData) error {
+	day := date.Format("2006-01-02")
+
+	tx, err := db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	for _, table := range []string{"food_log", "water_log"} {
+		if _, err := tx.Exec(
+			fmt.Sprintf("delete from %s where user_id = ? and date between ? and ?", table),
+			u.ID,
+			day+" 00:00:00",
+			day+" 23:59:59",
+		); err != nil {
+			return err
+		}
+	}
+
+	// Days without any logs still return a calorie goal so there is nothing worth saving
+	if len(food.Foods) == 0 && len(water.Water) == 0 {
+		if _, err := tx.Exec("delete from nutrition_daily where user_id = ? and date = ?", u.ID, day); err != nil {
+			return err
+		}
+		return tx.Commit()
+	}
+
+	for _, log := range food.Foods {
+		insertStatement := `insert into food_log
+		(log_id, user_id, date, name, brand, meal_type_id, amount, unit, calories, carbs, fat, fiber, protein, sodium)
+		values (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`
+
+		if _, err := tx.Exec(
+			insertStatement,
+			log.LogID,
+			u.ID,
+			log.LogDate,
+			log.LoggedFood.Name,
+			log.LoggedFood.Brand,
+			log.LoggedFood.MealTypeID,
+			log.LoggedFood.Amount,
+			log.LoggedFood.Unit.Name,
+			log.NutritionalValues.Calories,
+			log.NutritionalValues.Carbs,
+			log.NutritionalValues.Fat,
+			log.NutritionalValues.Fiber,
+			log.NutritionalValues.Protein,
+			log.NutritionalValues.Sodium,
+		); err != nil {
+			return err
+		}
+	}
+
+	for _, log := range water.Water {
+		if _, err := tx.Exec(
+			"insert into water_log (log_id, user_id, date, amount) values (?, ?, ?, ?)",
+			log.LogID,
+			u.ID,
+			day,
+			log.Amount,
+		); err != nil {
+			return err
+		}
+	}
+
+	// The totals change as more food is logged during the day so replace the saved ones
+	insertStatement := `insert into nutrition_daily
+	(user_id, date, calories, carbs, fat, fiber, protein, sodium, water, calorie_goal)
+	values (?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+	on duplicate key update
+		calories = values(calories),
+		carbs = values(carbs),
+		fat = values(fat),
+		fiber = values(fiber),
+		protein = values(protein),
+		sodium = values(sodium),
+		water = values(water),
+		calorie_goal = values(calorie_goal)`
+
+	if _, err := tx.Exec(
+		insertStatement,
+		u.ID,
+		day,
+		food.Summary.Calories,
+		food.Summary.Carbs,
+		food.Summary.Fat,
+		food.Summary.Fiber,
+		food.Summary.Protein,
+		food.Summary.Sodium,
+		water.Summary.Water,
+		food.Goals.Calories,
+	); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
@@ -0,0 +1,212 @@
+package fitbit
+
+import (
+	"context"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// fitbitCallsPerHour is the per user limit assumed until fitbit reports the actual one
+const fitbitCallsPerHour = 150
+
+// Priority decides if a request may use the calls held in reserve
+type Priority int
+
+const (
+	// PriorityBackfill requests are paced as the budget runs low and never use the reserve
+	PriorityBackfill Priority = iota
+	// PriorityLive requests keep the current day up to date and may use the reserve
+	PriorityLive
+)
+
+func (p Priority) String() string {
+	if p == PriorityLive {
+		return "live"
+	}
+	return "backfill"
+}
+
+type priorityKey struct{}
+
+// WithPriority returns a context that makes WaitForBudget treat requests with the given priority
+func WithPriority(ctx context.Context, priority Priority) context.Context {
+	return context.WithValue(ctx, priorityKey{}, priority)
+}
+
+func priorityFromContext(ctx context.Context) Priority {
+	if priority, ok := ctx.Value(priorityKey{}).(Priority); ok {
+		return priority
+	}
+	return PriorityBackfill
+}
+
+// RateLimitStatus is the last known state of the hourly api budget for a user
+type RateLimitStatus struct {
+	Limit     int       `json:"limit"`
+	Remaining int       `json:"remaining"`
+	Reserve   int       `json:"reserve"`
+	Reset     time.Time `json:"reset"`
+}
+
+// rateBudget tracks the calls a user has left for the hour from the Fitbit-Rate-Limit headers.
+// The zero value assumes a full budget that resets at the top of the hour.
+type rateBudget struct {
+	mu        sync.Mutex
+	limit     int
+	remaining int
+	reset     time.Time
+	lastCall  time.Time
+}
+
+// refill starts a new window once the reset time has passed. The caller must hold mu.
+func (b *rateBudget) refill(now time.Time) {
+	if !now.Before(b.reset) {
+		if b.limit == 0 {
+			b.limit = fitbitCallsPerHour
+		}
+		b.remaining = b.limit
+		// Fitbit resets the budget at the top of every hour
+		b.reset = now.Truncate(time.Hour).Add(time.Hour)
+	}
+}
+
+// take claims a call from the budget or returns how long to wait before trying again
+func (b *rateBudget) take(now time.Time, priority Priority, reserve int) time.Duration {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if wait := b.delay(now, priority, reserve); wait > 0 {
+		return wait
+	}
+
+	b.remaining--
+	b.lastCall = now
+	return 0
+}
+
+// delay returns how long a call with the priority has to wait for budget. The caller must hold mu.
+func (b *rateBudget) delay(now time.Time, priority Priority, reserve int) time.Duration {
+	b.refill(now)
+
+	available := b.remaining
+	if priority != PriorityLive {
+		available -= reserve
+	}
+	if available <= 0 {
+		return b.reset.Sub(now) + time.Second
+	}
+
+	// Once half the budget is used spread the rest of the backfill calls over what is left of the hour
+	// so there is always some budget available rather than stalling until the reset
+	if priority != PriorityLive && b.remaining < b.limit/2 {
+		interval := b.reset.Sub(now) / time.Duration(available)
+		if next := b.lastCall.Add(interval); next.After(now) {
+			return next.Sub(now)
+		}
+	}
+
+	return 0
+}
+
+// update replaces the estimate with the budget reported by fitbit
+func (b *rateBudget) update(statusCode int, header http.Header, now time.Time) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if limit, err := strconv.Atoi(header.Get("Fitbit-Rate-Limit-Limit")); err == nil {
+		b.limit = limit
+	}
+	if remaining, err := strconv.Atoi(header.Get("Fitbit-Rate-Limit-Remaining")); err == nil {
+		b.remaining = remaining
+	}
+	if reset, err := strconv.Atoi(header.Get("Fitbit-Rate-Limit-Reset")); err == nil {
+		b.reset = now.Add(time.Duration(reset) * time.Second)
+	}
+
+	if statusCode == http.StatusTooManyRequests {
+		b.remaining = 0
+	}
+}
+
+func (b *rateBudget) status(now time.Time) RateLimitStatus {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.refill(now)
+	return RateLimitStatus{
+		Limit:     b.limit,
+		Remaining: b.remaining,
+		Reset:     b.reset,
+	}
+}
+
+// rateLimitTransport records the budget reported on every response for the user
+type rateLimitTransport struct {
+	base http.RoundTripper
+	user *User
+}
+
+func (t *rateLimitTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	resp, err := t.base.RoundTrip(req)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	t.user.budget.update(resp.StatusCode, resp.Header, now)
+	recordRateLimit(t.user.ID, t.user.budget.status(now))
+
+	return resp, nil
+}
+
+// WaitForBudget blocks until the user has budget for another call with the priority set on the context.
+// Requests made without waiting, such as the ones made while a user logs in, always go straight through.
+func (c *Client) WaitForBudget(ctx context.Context, userID string) error {
+	user, err := c.GetUser(userID)
+	if err != nil {
+		return err
+	}
+
+	priority := priorityFromContext(ctx)
+	for {
+		wait := user.budget.take(time.Now(), priority, c.cfg.RateLimitReserve())
+		if wait <= 0 {
+			return nil
+		}
+
+		recordRateLimitWait(userID, priority, wait)
+		timer := time.NewTimer(wait)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return ctx.Err()
+		case <-timer.C:
+		}
+	}
+}
+
+// BudgetWait returns how long a call with the priority would have to wait for budget without claiming it
+func (c *Client) BudgetWait(userID string, priority Priority) time.Duration {
+	user, err := c.GetUser(userID)
+	if err != nil {
+		return 0
+	}
+
+	user.budget.mu.Lock()
+	defer user.budget.mu.Unlock()
+	return user.budget.delay(time.Now(), priority, c.cfg.RateLimitReserve())
+}
+
+// RateLimit returns the last known api budget for the user
+func (c *Client) RateLimit(userID string) (*RateLimitStatus, error) {
+	user, err := c.GetUser(userID)
+	if err != nil {
+		return nil, err
+	}
+
+	status := user.budget.status(time.Now())
+	status.Reserve = c.cfg.RateLimitReserve()
+	return &status, nil
+}
@@ -0,0 +1,135 @@
+package fitbit
+
+import (
+	"net/http"
+	"testing"
+	"time"
+)
+
+func TestRateBudgetDelay(t *testing.T) {
+	now := time.Date(2026, 1, 1, 10, 20, 0, 0, time.UTC)
+	reset := now.Add(40 * time.Minute)
+
+	tests := []struct {
+		name      string
+		remaining int
+		lastCall  time.Time
+		priority  Priority
+		want      time.Duration
+	}{
+		{"live uses the reserve", 10, time.Time{}, PriorityLive, 0},
+		{"backfill waits at the reserve", 10, time.Time{}, PriorityBackfill, 40*time.Minute + time.Second},
+		{"backfill above the reserve", 11, time.Time{}, PriorityBackfill, 0},
+		{"live waits once nothing is left", 0, time.Time{}, PriorityLive, 40*time.Minute + time.Second},
+		{"no pacing while over half is left", 100, now, PriorityBackfill, 0},
+		{"backfill paced after half is used", 50, now.Add(-30 * time.Second), PriorityBackfill, 30 * time.Second},
+		{"paced call already due", 50, now.Add(-2 * time.Minute), PriorityBackfill, 0},
+		{"live is never paced", 50, now, PriorityLive, 0},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			b := &rateBudget{limit: 150, remaining: test.remaining, reset: reset, lastCall: test.lastCall}
+			if got := b.delay(now, test.priority, 10); got != test.want {
+				t.Errorf("delay() = %s, want %s", got, test.want)
+			}
+		})
+	}
+}
+
+func TestRateBudgetTake(t *testing.T) {
+	now := time.Date(2026, 1, 1, 10, 20, 0, 0, time.UTC)
+
+	b := &rateBudget{limit: 150, remaining: 11, reset: now.Add(time.Minute)}
+	if wait := b.take(now, PriorityBackfill, 10); wait != 0 {
+		t.Fatalf("take() = %s, want 0", wait)
+	}
+	if b.remaining != 10 || !b.lastCall.Equal(now) {
+		t.Errorf("take() left remaining %d and last call %s", b.remaining, b.lastCall)
+	}
+	if wait := b.take(now, PriorityBackfill, 10); wait == 0 {
+		t.Errorf("take() claimed a call from the reserve for a backfill")
+	}
+	if b.remaining != 10 {
+		t.Errorf("waiting take() changed remaining to %d", b.remaining)
+	}
+}
+
+func TestRateBudgetUpdate(t *testing.T) {
+	now := time.Date(2026, 1, 1, 10, 20, 0, 0, time.UTC)
+
+	tests := []struct {
+		name          string
+		statusCode    int
+		header        map[string]string
+		wantLimit     int
+		wantRemaining int
+		wantReset     time.Time
+	}{
+		{
+			name:       "headers replace the estimate",
+			statusCode: http.StatusOK,
+			header: map[string]string{
+				"Fitbit-Rate-Limit-Limit":     "150",
+				"Fitbit-Rate-Limit-Remaining": "42",
+				"Fitbit-Rate-Limit-Reset":     "600",
+			},
+			wantLimit:     150,
+			wantRemaining: 42,
+			wantReset:     now.Add(10 * time.Minute),
+		},
+		{
+			name:          "missing headers keep the estimate",
+			statusCode:    http.StatusOK,
+			header:        map[string]string{},
+			wantLimit:     100,
+			wantRemaining: 80,
+			wantReset:     now.Add(time.Hour),
+		},
+		{
+			name:       "too many requests empties the budget",
+			statusCode: http.StatusTooManyRequests,
+			header: map[string]string{
+				"Fitbit-Rate-Limit-Remaining": "5",
+				"Fitbit-Rate-Limit-Reset":     "120",
+			},
+			wantLimit:     100,
+			wantRemaining: 0,
+			wantReset:     now.Add(2 * time.Minute),
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			header := make(http.Header)
+			for key, value := range test.header {
+				header.Set(key, value)
+			}
+
+			b := &rateBudget{limit: 100, remaining: 80, reset: now.Add(time.Hour)}
+			b.update(test.statusCode, header, now)
+			if b.limit != test.wantLimit || b.remaining != test.wantRemaining || !b.reset.Equal(test.wantReset) {
+				t.Errorf("update() = limit %d remaining %d reset %s, want limit %d remaining %d reset %s",
+					b.limit, b.remaining, b.reset, test.wantLimit, test.wantRemaining, test.wantReset)
+			}
+		})
+	}
+}
+
+func TestRateBudgetRefill(t *testing.T) {
+	now := time.Date(2026, 1, 1, 10, 20, 0, 0, time.UTC)
+
+	var b rateBudget
+	status := b.status(now)
+	if status.Limit != fitbitCallsPerHour || status.Remaining != fitbitCallsPerHour {
+		t.Errorf("zero budget = %d of %d, want a full budget", status.Remaining, status.Limit)
+	}
+	if want := time.Date(2026, 1, 1, 11, 0, 0, 0, time.UTC); !status.Reset.Equal(want) {
+		t.Errorf("zero budget resets at %s, want %s", status.Reset, want)
+	}
+
+	b = rateBudget{limit: 150, remaining: 0, reset: now}
+	if status := b.status(now); status.Remaining != 150 {
+		t.Errorf("budget after the reset has %d remaining, want 150", status.Remaining)
+	}
+}
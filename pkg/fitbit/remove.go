@@ -0,0 +1,187 @@
+package fitbit
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"log"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+const revokeURL = "https://api.fitbit.com/oauth2/revoke"
+
+// ErrUserNotFound is returned when removing a user that has never been stored
+var ErrUserNotFound = errors.New("user not found")
+
+// userTables are the tables with a user_id column purged when a user is removed with their data
+var userTables = []string{
+	"heart_data",
+	"heart_rest",
+	"heart_zone",
+	"sleep_log",
+	"activity_daily",
+	"activity_data",
+	"hrv_daily",
+	"hrv_intraday",
+	"spo2_daily",
+	"spo2_intraday",
+	"breathing_rate",
+	"skin_temp",
+	"weight_log",
+	"body_fat_log",
+	"activity_log",
+	"device",
+	"azm_daily",
+	"azm_intraday",
+	"cardio_score",
+	"food_log",
+	"water_log",
+	"nutrition_daily",
+	"ecg_reading",
+	"irn_alert",
+	"lifetime_stats",
+	"badge",
+	"sync_state",
+	"user_token",
+	"user",
+}
+
+// RemoveUser revokes the users token with fitbit and stops collecting their data. When purge is set
+// every row stored for the user is deleted, otherwise only their token is removed.
+// The user is removed locally even when fitbit fails to revoke the token in which case an error is still returned.
+// A user without a token, such as one already removed, can still have their data purged.
+func (c *Client) RemoveUser(userID string, purge bool) error {
+	user, err := c.GetUser(userID)
+	if err != nil {
+		stored, err := c.userStored(userID)
+		if err != nil {
+			return err
+		}
+		if !stored {
+			return fmt.Errorf("%w: %s", ErrUserNotFound, userID)
+		}
+		deleteUserMetrics(userID)
+		return c.deleteUserData(userID, purge)
+	}
+
+	// Removing a user is done on request so it may use the budget held in reserve
+	ctx, cancel := context.WithTimeout(WithPriority(context.Background(), PriorityLive), time.Minute)
+	defer cancel()
+
+	// The subscription has to be removed while the token is still valid
+	if c.cfg.Fitbit.Subscriptions.Enabled {
+		if err := c.Unsubscribe(ctx, userID); err != nil {
+			log.Printf("error removing subscription for %s: %s", userID, err)
+		}
+	}
+
+	revokeErr := c.revokeToken(ctx, user)
+
+	c.usersMu.Lock()
+	for i, u := range c.users {
+		if u.ID == userID {
+			c.users = append(c.users[:i], c.users[i+1:]...)
+			break
+		}
+	}
+	c.usersMu.Unlock()
+	user.httpClient.CloseIdleConnections()
+	deleteUserMetrics(userID)
+
+	if err := c.deleteUserData(userID, purge); err != nil {
+		return err
+	}
+
+	if revokeErr != nil {
+		return fmt.Errorf("user %s removed but the token could not be revoked: %s", userID, revokeErr)
+	}
+	return nil
+}
+
+// userStored returns true when the user has a row or a token saved
+func (c *Client) userStored(userID string) (bool, error) {
+	var count int
+	query := "select (select count(id) from user where id = ?) + (select count(user_id) from user_token where user_id = ?)"
+	if err := c.db.GetDB().QueryRow(query, userID, userID).Scan(&count); err != nil {
+		return false, err
+	}
+	return count > 0, nil
+}
+
+// revokeToken revokes the refresh token which also invalidates every access token issued from it
+func (c *Client) revokeToken(ctx context.Context, user *User) error {
+	if err := c.WaitForBudget(ctx, user.ID); err != nil {
+		return err
+	}
+
+	user.tokenMu.Lock()
+	token := user.token.RefreshToken
+	user.tokenMu.Unlock()
+
+	req, err := http.NewRequest(http.MethodPost, revokeURL, strings.NewReader(url.Values{"token": {token}}.Encode()))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.SetBasicAuth(c.clientID, c.clientSecret)
+
+	// The app credentials authenticate the request instead of the users token so the oauth client can't send it,
+	// the budget is still tracked the same as every other call for the user
+	client := &http.Client{Transport: &rateLimitTransport{base: http.DefaultTransport, user: user}}
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	recordAPICall(resp.StatusCode)
+
+	if resp.StatusCode > 299 {
+		body, _ := ioutil.ReadAll(resp.Body)
+		return fmt.Errorf("ERROR (%d): %s", resp.StatusCode, strings.TrimSpace(string(body)))
+	}
+
+	return nil
+}
+
+func (c *Client) deleteUserData(userID string, purge bool) error {
+	tx, err := c.db.GetDB().Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if !purge {
+		if _, err := tx.Exec("delete from user_token where user_id = ?", userID); err != nil {
+			return err
+		}
+		return tx.Commit()
+	}
+
+	// Child rows are only keyed by their log so they have to go before the logs themselves
+	childDeletes := []string{
+		"delete from sleep_stage where log_id in (select log_id from sleep_log where user_id = ?)",
+		"delete from sleep_level where log_id in (select log_id from sleep_log where user_id = ?)",
+		"delete from activity_trackpoint where log_id in (select log_id from activity_log where user_id = ?)",
+	}
+	for _, query := range childDeletes {
+		if _, err := tx.Exec(query, userID); err != nil {
+			return err
+		}
+	}
+
+	for _, table := range userTables {
+		column := "user_id"
+		if table == "user" {
+			column = "id"
+		}
+		if _, err := tx.Exec(fmt.Sprintf("delete from %s where %s = ?", table, column), userID); err != nil {
+			return err
+		}
+	}
+
+	return tx.Commit()
+}
@@ -0,0 +1,231 @@
+package fitbit
+
+import (
+	"database/sql"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/bah2830/fitbit-exporter/pkg/database"
+)
+
+const sleepPath = "/user/%s/sleep/date"
+
+type SleepOptions struct {
+	StartDate *time.Time
+	EndDate   *time.Time
+}
+
+type SleepData struct {
+	Sleep []SleepLog `json:"sleep"`
+}
+
+type SleepLog struct {
+	LogID               int64       `json:"logId"`
+	DateOfSleep         string      `json:"dateOfSleep"`
+	StartTime           string      `json:"startTime"`
+	EndTime             string      `json:"endTime"`
+	Duration            int64       `json:"duration"`
+	Efficiency          int         `json:"efficiency"`
+	IsMainSleep         bool        `json:"isMainSleep"`
+	MinutesAsleep       int         `json:"minutesAsleep"`
+	MinutesAwake        int         `json:"minutesAwake"`
+	MinutesToFallAsleep int         `json:"minutesToFallAsleep"`
+	MinutesAfterWakeup  int         `json:"minutesAfterWakeup"`
+	TimeInBed           int         `json:"timeInBed"`
+	Type                string      `json:"type"`
+	Levels              SleepLevels `json:"levels"`
+}
+
+type SleepLevels struct {
+	Summary   map[string]SleepStage `json:"summary"`
+	Data      []SleepLevel          `json:"data"`
+	ShortData []SleepLevel          `json:"shortData"`
+}
+
+type SleepStage struct {
+	Count               int `json:"count"`
+	Minutes             int `json:"minutes"`
+	ThirtyDayAvgMinutes int `json:"thirtyDayAvgMinutes"`
+}
+
+type SleepLevel struct {
+	DateTime string `json:"dateTime"`
+	Level    string `json:"level"`
+	Seconds  int    `json:"seconds"`
+}
+
+func (c *Client) GetSleepLogs(user string, opts SleepOptions) (*SleepData, error) {
+	path, err := opts.toPath(user)
+	if err != nil {
+		return nil, err
+	}
+
+	userClient, err := c.GetUser(user)
+	if err != nil {
+		return nil, err
+	}
+
+	data := &SleepData{}
+	if err := c.get(userClient.httpClient, path, data); err != nil {
+		return nil, err
+	}
+
+	return data, nil
+}
+
+func (o SleepOptions) toPath(user string) (string, error) {
+	path := basePathV12 + fmt.Sprintf(sleepPath, user)
+
+	if o.StartDate == nil {
+		path += "/today"
+	} else {
+		path += "/" + o.StartDate.Format("2006-01-02")
+	}
+
+	// Sleep ranges are limited to 100 days by the api
+	if o.EndDate != nil {
+		if o.StartDate == nil {
+			return "", fmt.Errorf("sleep end date given without a start date")
+		}
+		if o.EndDate.Sub(*o.StartDate) > 100*24*time.Hour {
+			return "", fmt.Errorf("sleep date range can not exceed 100 days")
+		}
+		path += "/" + o.EndDate.Format("2006-01-02")
+	}
+
+	path += ".json"
+
+	return path, nil
+}
+
+func (u *User) SaveSleepData(db *sql.DB, data *SleepData) error {
+	for _, log := range data.Sleep {
+		// Logs can be edited or reprocessed after they are first saved so always replace them
+		if err := u.saveSleepLog(db, log); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (u *User) saveSleepLog(db *sql.DB, log SleepLog) error {
+	startTime, err := formatFitbitDateTime(log.StartTime)
+	if err != nil {
+		return err
+	}
+	endTime, err := formatFitbitDateTime(log.EndTime)
+	if err != nil {
+		return err
+	}
+
+	tx, err := db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	insertStatement := `insert into sleep_log
+	(log_id, user_id, date, start_time, end_time, duration, efficiency, is_main_sleep,
+	minutes_asleep, minutes_awake, minutes_to_fall_asleep, minutes_after_wakeup, time_in_bed, type)
+	values (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+	on duplicate key update
+		date = values(date),
+		start_time = values(start_time),
+		end_time = values(end_time),
+		duration = values(duration),
+		efficiency = values(efficiency),
+		is_main_sleep = values(is_main_sleep),
+		minutes_asleep = values(minutes_asleep),
+		minutes_awake = values(minutes_awake),
+		minutes_to_fall_asleep = values(minutes_to_fall_asleep),
+		minutes_after_wakeup = values(minutes_after_wakeup),
+		time_in_bed = values(time_in_bed),
+		type = values(type)`
+
+	if _, err := tx.Exec(
+		insertStatement,
+		log.LogID,
+		u.ID,
+		log.DateOfSleep,
+		startTime,
+		endTime,
+		log.Duration/1000,
+		log.Efficiency,
+		log.IsMainSleep,
+		log.MinutesAsleep,
+		log.MinutesAwake,
+		log.MinutesToFallAsleep,
+		log.MinutesAfterWakeup,
+		log.TimeInBed,
+		log.Type,
+	); err != nil {
+		return err
+	}
+
+	// The stages and levels of an edited log no longer line up with the old ones so replace all of them
+	for _, query := range []string{"delete from sleep_stage where log_id = ?", "delete from sleep_level where log_id = ?"} {
+		if _, err := tx.Exec(query, log.LogID); err != nil {
+			return err
+		}
+	}
+
+	for stage, summary := range log.Levels.Summary {
+		if _, err := tx.Exec(
+			"insert into sleep_stage (log_id, stage, count, minutes, thirty_day_avg_minutes) values (?, ?, ?, ?, ?)",
+			log.LogID,
+			stage,
+			summary.Count,
+			summary.Minutes,
+			summary.ThirtyDayAvgMinutes,
+		); err != nil {
+			return err
+		}
+	}
+
+	// Short wake periods are reported separately from the main levels but are stored together
+	levels := make([]SleepLevel, 0, len(log.Levels.Data)+len(log.Levels.ShortData))
+	levels = append(levels, log.Levels.Data...)
+	levels = append(levels, log.Levels.ShortData...)
+
+	// Insert 200 levels at a time to help take load off the database connection
+	insertQuery := "insert into sleep_level (log_id, date, level, seconds, short) values "
+	for i := 0; i < len(levels); i += 200 {
+		end := i + 200
+		if end > len(levels) {
+			end = len(levels)
+		}
+
+		values := make([]string, 0, end-i)
+		for j, level := range levels[i:end] {
+			date, err := formatFitbitDateTime(level.DateTime)
+			if err != nil {
+				return err
+			}
+			values = append(values, fmt.Sprintf(
+				"(%d, '%s', '%s', %d, %t)",
+				log.LogID,
+				date,
+				level.Level,
+				level.Seconds,
+				i+j >= len(log.Levels.Data),
+			))
+		}
+
+		if _, err := tx.Exec(insertQuery + strings.Join(values, ", ")); err != nil {
+			return err
+		}
+	}
+
+	return tx.Commit()
+}
+
+// formatFitbitDateTime converts the timestamp format used by the fitbit api to one the database accepts
+func formatFitbitDateTime(in string) (string, error) {
+	t, err := time.Parse(fitbitDateTimeFormat, in)
+	if err != nil {
+		return "", err
+	}
+	return t.Format(database.DateTimeFormat), nil
+}
@@ -0,0 +1,95 @@
+package fitbit
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha1"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+)
+
+const subscriptionPath = "/user/-/apiSubscriptions/%s.json"
+
+// Collections fitbit sends notifications for
+const (
+	CollectionActivities        = "activities"
+	CollectionBody              = "body"
+	CollectionFoods             = "foods"
+	CollectionSleep             = "sleep"
+	CollectionUserRevokedAccess = "userRevokedAccess"
+)
+
+// ErrInvalidSignature is returned for notifications that were not signed by fitbit
+var ErrInvalidSignature = errors.New("invalid notification signature")
+
+// Notification tells that data in a collection changed for a user on the given date
+type Notification struct {
+	CollectionType string `json:"collectionType"`
+	Date           string `json:"date"`
+	OwnerID        string `json:"ownerId"`
+	OwnerType      string `json:"ownerType"`
+	SubscriptionID string `json:"subscriptionId"`
+}
+
+// Day returns the date of the notification in local time
+func (n Notification) Day() (time.Time, error) {
+	return time.ParseInLocation("2006-01-02", n.Date, time.Local)
+}
+
+// Subscribe registers a subscription for every collection of the user. The user id is used as the
+// subscription id so subscribing again for an existing subscription does nothing.
+// The request waits for budget with the priority set on the context like every other api call.
+func (c *Client) Subscribe(ctx context.Context, userID string) error {
+	return c.subscription(ctx, http.MethodPost, userID)
+}
+
+// Unsubscribe removes the subscription created by Subscribe
+func (c *Client) Unsubscribe(ctx context.Context, userID string) error {
+	return c.subscription(ctx, http.MethodDelete, userID)
+}
+
+func (c *Client) subscription(ctx context.Context, method, userID string) error {
+	user, err := c.GetUser(userID)
+	if err != nil {
+		return err
+	}
+
+	if err := c.WaitForBudget(ctx, userID); err != nil {
+		return err
+	}
+
+	req, err := http.NewRequest(method, basePath+fmt.Sprintf(subscriptionPath, userID), nil)
+	if err != nil {
+		return err
+	}
+	if subscriberID := c.cfg.Fitbit.Subscriptions.SubscriberID; subscriberID != "" {
+		req.Header.Set("X-Fitbit-Subscriber-Id", subscriberID)
+	}
+
+	_, err = c.do(user.httpClient, req)
+	return err
+}
+
+// ParseNotifications checks the body was signed by fitbit using the client secret and returns the notifications in it
+func (c *Client) ParseNotifications(body []byte, signature string) ([]Notification, error) {
+	expected, err := base64.StdEncoding.DecodeString(signature)
+	if err != nil {
+		return nil, ErrInvalidSignature
+	}
+
+	mac := hmac.New(sha1.New, []byte(c.clientSecret+"&"))
+	mac.Write(body)
+	if !hmac.Equal(mac.Sum(nil), expected) {
+		return nil, ErrInvalidSignature
+	}
+
+	var notifications []Notification
+	if err := json.Unmarshal(body, &notifications); err != nil {
+		return nil, err
+	}
+	return notifications, nil
+}
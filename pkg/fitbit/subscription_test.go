@@ -0,0 +1,43 @@
+package fitbit
+
+import "testing"
+
+func TestParseNotifications(t *testing.T) {
+	body := `[{"collectionType":"sleep","date":"2026-01-02","ownerId":"ABC123","ownerType":"user","subscriptionId":"ABC123"}]`
+	// Signed with the client secret followed by & as fitbit does
+	signature := "LL24snseCaOAJqOTKxYnn0n3FNg="
+
+	tests := []struct {
+		name      string
+		body      string
+		signature string
+		wantErr   error
+	}{
+		{"valid signature", body, signature, nil},
+		{"tampered body", `[{"collectionType":"sleep","date":"2026-01-02","ownerId":"XYZ789","ownerType":"user","subscriptionId":"ABC123"}]`, signature, ErrInvalidSignature},
+		{"wrong signature", body, "AAAAAAAAAAAAAAAAAAAAAAAAAAA=", ErrInvalidSignature},
+		{"signature not base64", body, "not base64!", ErrInvalidSignature},
+		{"missing signature", body, "", ErrInvalidSignature},
+	}
+
+	c := &Client{clientSecret: "client-secret"}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			notifications, err := c.ParseNotifications([]byte(test.body), test.signature)
+			if err != test.wantErr {
+				t.Fatalf("ParseNotifications() error = %v, want %v", err, test.wantErr)
+			}
+			if err != nil {
+				return
+			}
+
+			if len(notifications) != 1 {
+				t.Fatalf("got %d notifications, want 1", len(notifications))
+			}
+			n := notifications[0]
+			if n.CollectionType != CollectionSleep || n.OwnerID != "ABC123" || n.Date != "2026-01-02" {
+				t.Errorf("got notification %+v", n)
+			}
+		})
+	}
+}
@@ -0,0 +1,135 @@
+package fitbit
+
+import (
+	"database/sql"
+	"time"
+
+	"github.com/bah2830/fitbit-exporter/pkg/database"
+)
+
+// SyncState records how much of the history of a data type has been saved for a user
+type SyncState struct {
+	DataType      string     `json:"dataType"`
+	OldestDate    *time.Time `json:"oldestDate,omitempty"`
+	NewestDate    *time.Time `json:"newestDate,omitempty"`
+	Complete      bool       `json:"complete"`
+	LastSuccess   *time.Time `json:"lastSuccess,omitempty"`
+	LastError     string     `json:"lastError,omitempty"`
+	LastErrorTime *time.Time `json:"lastErrorTime,omitempty"`
+}
+
+// Failing reports if the last attempt to sync the data type failed
+func (s SyncState) Failing() bool {
+	return s.LastErrorTime != nil && (s.LastSuccess == nil || s.LastErrorTime.After(*s.LastSuccess))
+}
+
+// SaveSyncProgress extends the synced range of the data type to include oldest and newest.
+// Once complete is set it stays set so later runs only need to catch up on new days.
+func (u *User) SaveSyncProgress(db *sql.DB, dataType string, oldest, newest time.Time, complete bool) error {
+	insertStatement := `insert into sync_state
+	(user_id, data_type, oldest_date, newest_date, complete, last_success)
+	values (?, ?, ?, ?, ?, ?)
+	on duplicate key update
+		oldest_date = coalesce(least(oldest_date, values(oldest_date)), values(oldest_date)),
+		newest_date = coalesce(greatest(newest_date, values(newest_date)), values(newest_date)),
+		complete = complete or values(complete),
+		last_success = values(last_success)`
+
+	_, err := db.Exec(
+		insertStatement,
+		u.ID,
+		dataType,
+		oldest.Format(database.DateTimeFormat),
+		newest.Format(database.DateTimeFormat),
+		complete,
+		time.Now().Format(database.DateTimeFormat),
+	)
+	return err
+}
+
+// SaveSyncError records the last error hit while syncing the data type
+func (u *User) SaveSyncError(db *sql.DB, dataType string, syncErr error) error {
+	insertStatement := `insert into sync_state
+	(user_id, data_type, last_error, last_error_time)
+	values (?, ?, ?, ?)
+	on duplicate key update
+		last_error = values(last_error),
+		last_error_time = values(last_error_time)`
+
+	_, err := db.Exec(
+		insertStatement,
+		u.ID,
+		dataType,
+		syncErr.Error(),
+		time.Now().Format(database.DateTimeFormat),
+	)
+	return err
+}
+
+// GetSyncState returns the sync state of the data type or an empty state if it has never been synced
+func (c *Client) GetSyncState(user, dataType string) (*SyncState, error) {
+	states, err := c.getSyncStates("select "+syncStateColumns+" from sync_state where user_id = ? and data_type = ?", user, dataType)
+	if err != nil {
+		return nil, err
+	}
+	if len(states) == 0 {
+		return &SyncState{DataType: dataType}, nil
+	}
+	return &states[0], nil
+}
+
+// GetSyncStates returns the sync state of every data type synced for the user
+func (c *Client) GetSyncStates(user string) ([]SyncState, error) {
+	return c.getSyncStates("select "+syncStateColumns+" from sync_state where user_id = ? order by data_type", user)
+}
+
+const syncStateColumns = "data_type, oldest_date, newest_date, complete, last_success, last_error, last_error_time"
+
+func (c *Client) getSyncStates(query string, args ...interface{}) ([]SyncState, error) {
+	rows, err := c.db.GetDB().Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	results := make([]SyncState, 0)
+	for rows.Next() {
+		var state SyncState
+		var oldest, newest, lastSuccess, lastError, lastErrorTime sql.NullString
+		if err := rows.Scan(
+			&state.DataType,
+			&oldest,
+			&newest,
+			&state.Complete,
+			&lastSuccess,
+			&lastError,
+			&lastErrorTime,
+		); err != nil {
+			return nil, err
+		}
+
+		for _, field := range []struct {
+			value sql.NullString
+			dest  **time.Time
+		}{
+			{oldest, &state.OldestDate},
+			{newest, &state.NewestDate},
+			{lastSuccess, &state.LastSuccess},
+			{lastErrorTime, &state.LastErrorTime},
+		} {
+			if !field.value.Valid {
+				continue
+			}
+			// Dates are stored in local time without a zone
+			t, err := time.ParseInLocation(database.DateTimeFormat, field.value.String, time.Local)
+			if err != nil {
+				return nil, err
+			}
+			*field.dest = &t
+		}
+		state.LastError = lastError.String
+
+		results = append(results, state)
+	}
+	return results, rows.Err()
+}
@@ -0,0 +1,115 @@
+package fitbit
+
+import (
+	"database/sql"
+	"log"
+	"net/http"
+
+	"github.com/bah2830/fitbit-exporter/pkg/database"
+	"golang.org/x/oauth2"
+)
+
+// savingTokenSource saves the token for the user as soon as the wrapped source returns a new one.
+// This keeps the stored refresh token valid if the process exits before the client is closed.
+type savingTokenSource struct {
+	src  oauth2.TokenSource
+	user *User
+	db   *sql.DB
+}
+
+func (s *savingTokenSource) Token() (*oauth2.Token, error) {
+	token, err := s.src.Token()
+	if err != nil {
+		return nil, err
+	}
+
+	// The token is still usable so a failed write is only logged, it will be tried again on the next refresh or close
+	if err := s.user.setToken(s.db, token); err != nil {
+		log.Printf("error saving refreshed token for %s: %s", s.user.ID, err)
+	}
+
+	return token, nil
+}
+
+// newUserHTTPClient returns an http client for the user that refreshes and saves the users token as needed
+// and keeps track of the rate limit budget reported on each response
+func (c *Client) newUserHTTPClient(user *User) *http.Client {
+	client := oauth2.NewClient(oauth2.NoContext, &savingTokenSource{
+		src:  c.oauthConfig.TokenSource(oauth2.NoContext, user.token),
+		user: user,
+		db:   c.db.GetDB(),
+	})
+	client.Transport = &rateLimitTransport{base: client.Transport, user: user}
+	return client
+}
+
+// setToken replaces the users token and saves it when it differs from the current one
+func (u *User) setToken(db *sql.DB, token *oauth2.Token) error {
+	u.tokenMu.Lock()
+	defer u.tokenMu.Unlock()
+
+	if u.token != nil && u.token.AccessToken == token.AccessToken && u.token.RefreshToken == token.RefreshToken {
+		return nil
+	}
+
+	u.token = token
+	return u.writeToken(db, false)
+}
+
+// saveToken stores the token for a user that just logged in
+func (u *User) saveToken(db *sql.DB) error {
+	u.tokenMu.Lock()
+	defer u.tokenMu.Unlock()
+
+	return u.writeToken(db, true)
+}
+
+// updateToken stores the current token only if the user still has one saved
+func (u *User) updateToken(db *sql.DB) error {
+	u.tokenMu.Lock()
+	defer u.tokenMu.Unlock()
+
+	return u.writeToken(db, false)
+}
+
+// writeToken replaces the stored token for the user. Unless create is set nothing is written when the user has no
+// stored token as they have been removed, possibly by another process. The caller must hold tokenMu.
+func (u *User) writeToken(db *sql.DB, create bool) error {
+	tx, err := db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if !create {
+		var count int
+		if err := tx.QueryRow("select count(user_id) from user_token where user_id = ? for update", u.ID).Scan(&count); err != nil {
+			return err
+		}
+		if count == 0 {
+			return nil
+		}
+	}
+
+	// Delete all previous tokens for the user
+	if _, err := tx.Exec("delete from user_token where user_id = ?", u.ID); err != nil {
+		return err
+	}
+
+	insertStatement := `insert into user_token
+	(user_id, access_token, refresh_token, token_type, expiration)
+	values (?, ?, ?, ?, ?)`
+
+	if _, err := tx.Exec(
+		insertStatement,
+		u.ID,
+		u.token.AccessToken,
+		u.token.RefreshToken,
+		u.token.TokenType,
+		u.token.Expiry.Format(database.DateTimeFormat),
+	); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
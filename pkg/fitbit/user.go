@@ -5,12 +5,17 @@ import (
 	"fmt"
 	"net/http"
 	"strings"
+	"sync"
 	"time"
 
 	"github.com/bah2830/fitbit-exporter/pkg/database"
 	"golang.org/x/oauth2"
 )
 
+// heartDataInsertChunkSize keeps a full day of 1sec data to a few dozen statements
+// while staying well under the default mysql max packet size
+const heartDataInsertChunkSize = 1000
+
 type UserResponse struct {
 	User *User `json:"user"`
 }
@@ -22,7 +27,9 @@ type User struct {
 	MemberSince string `json:"memberSince"`
 
 	token      *oauth2.Token
+	tokenMu    sync.Mutex
 	httpClient *http.Client
+	budget     rateBudget
 }
 
 func (c *Client) GetCurrentUser(client *http.Client) (*User, error) {
@@ -95,53 +102,38 @@ func (c *Client) getAllUsersWithTokens() ([]*User, error) {
 		}
 
 		// If a previous token was found and it hasn't expired then use it rather than wait for auth
-		users = append(users, &User{
+		u := &User{
 			ID:          userID,
 			FullName:    user,
 			DisplayName: displayName,
 			MemberSince: memberSince,
 			token:       token,
-			httpClient:  c.oauthConfig.Client(oauth2.NoContext, token),
-		})
+		}
+		u.httpClient = c.newUserHTTPClient(u)
+		users = append(users, u)
 	}
 
 	return users, nil
 }
 
-func (u *User) saveToken(db *sql.DB) error {
-	// Delete all previous tokens for the user
-	if _, err := db.Exec("delete from user_token where user_id = ?", u.ID); err != nil {
-		return err
-	}
-
-	insertStatement := `insert into user_token
-	(user_id, access_token, refresh_token, token_type, expiration)
-	values (?, ?, ?, ?, ?)`
-
-	_, err := db.Exec(
-		insertStatement,
-		u.ID,
-		u.token.AccessToken,
-		u.token.RefreshToken,
-		u.token.TokenType,
-		u.token.Expiry.Format(database.DateTimeFormat),
-	)
-	return err
-}
-
 func (u *User) SaveHeartRateData(db *sql.DB, data *HeartRateData) error {
 	var day string
 	for _, dayOverview := range data.OverviewByDay {
 		day = dayOverview.Date
 
-		// Save the resting heart rate if it hasn't been already
-		var count int
-		if err := db.QueryRow("select count(*) from heart_rest where user_id = ? and date = ?", u.ID, day).Scan(&count); err != nil {
-			return err
+		// Range requests return every day in the range even before the user had a tracker
+		var zoneMinutes int
+		for _, zone := range dayOverview.Value.Zones {
+			zoneMinutes += zone.Minutes
+		}
+		if dayOverview.Value.RestingHeartRate == 0 && zoneMinutes == 0 {
+			continue
 		}
-		if count == 0 && dayOverview.Value.RestingHeartRate != 0 {
+
+		// Summaries of recent days change as the tracker syncs so replace the saved values
+		if dayOverview.Value.RestingHeartRate != 0 {
 			_, err := db.Exec(
-				"insert into heart_rest (user_id, date, value) values (?, ?, ?)",
+				"insert into heart_rest (user_id, date, value) values (?, ?, ?) on duplicate key update value = values(value)",
 				u.ID,
 				day,
 				dayOverview.Value.RestingHeartRate,
@@ -151,84 +143,42 @@ func (u *User) SaveHeartRateData(db *sql.DB, data *HeartRateData) error {
 			}
 		}
 
-		// Save the zone data if not already exists
 		for _, zone := range dayOverview.Value.Zones {
-			var count int
-			r := db.QueryRow(
-				"select count(*) from heart_zone where user_id = ? and date = ? and type = ?",
+			_, err := db.Exec(
+				`insert into heart_zone (user_id, date, type, minutes, calories) values (?, ?, ?, ?, ?)
+				on duplicate key update minutes = values(minutes), calories = values(calories)`,
 				u.ID,
 				day,
 				zone.Name,
+				zone.Minutes,
+				zone.CaloriesOut,
 			)
-			if err := r.Scan(&count); err != nil {
+			if err != nil {
 				return err
 			}
-			if count == 0 {
-				_, err := db.Exec(
-					"insert into heart_zone (user_id, date, type, minutes, calories) values (?, ?, ?, ?, ?)",
-					u.ID,
-					day,
-					zone.Name,
-					zone.Minutes,
-					zone.CaloriesOut,
-				)
-				if err != nil {
-					return err
-				}
-			}
 		}
 	}
 
-	// Get list of every existing datapoint on this day
-	existingDates := make([]string, 0, 2000)
-	rows, err := db.Query(
-		"select date from heart_data where user_id = ? and date between ? and ?",
-		u.ID,
-		day+" 00:00:00",
-		day+" 23:59:59",
-	)
-	if err != nil {
-		return err
-	}
-	for rows.Next() {
-		var date string
-		if err := rows.Scan(&date); err != nil {
-			return err
-		}
-		existingDates = append(existingDates, date)
+	if data.IntraDay == nil || len(data.IntraDay.Data) == 0 {
+		return nil
 	}
 
-	// Breakup any intraday data into chunks of 200 to bulk insert
-	var intradayChunks [][]string
-	currentChunk := make([]string, 0, 200)
-
-INTRA_LOOP:
-	for i, d := range data.IntraDay.Data {
+	values := make([]string, 0, len(data.IntraDay.Data))
+	for _, d := range data.IntraDay.Data {
 		if d.Value == 0 {
 			continue
 		}
-
-		// Check if the date was alround found in the database
-		for _, date := range existingDates {
-			if date == day+" "+d.Time {
-				continue INTRA_LOOP
-			}
-		}
-
-		currentChunk = append(currentChunk, fmt.Sprintf("('%s', '%s', %d)", u.ID, day+" "+d.Time, d.Value))
-		if i != 0 && i%200 == 0 {
-			intradayChunks = append(intradayChunks, currentChunk)
-			currentChunk = make([]string, 0, 200)
-		}
-	}
-	if len(currentChunk) > 0 {
-		intradayChunks = append(intradayChunks, currentChunk)
+		values = append(values, fmt.Sprintf("('%s', '%s', %d)", u.ID, day+" "+d.Time, d.Value))
 	}
 
-	// Insert 200 data points at a time to help take load off the database connection
+	// Insert the data points in chunks to help take load off the database connection
 	insertQuery := "insert into heart_data (user_id, date, value) values "
-	for _, chunk := range intradayChunks {
-		if _, err := db.Exec(insertQuery + strings.Join(chunk, ", ")); err != nil {
+	for i := 0; i < len(values); i += heartDataInsertChunkSize {
+		end := i + heartDataInsertChunkSize
+		if end > len(values) {
+			end = len(values)
+		}
+		if _, err := db.Exec(insertQuery + strings.Join(values[i:end], ", ") + " on duplicate key update value = values(value)"); err != nil {
 			return err
 		}
 	}
@@ -0,0 +1,268 @@
+package fitbit
+
+import (
+	"database/sql"
+	"fmt"
+	"strings"
+	"time"
+)
+
+const (
+	spo2Path          = "/user/%s/spo2/date"
+	breathingRatePath = "/user/%s/br/date"
+	skinTempPath      = "/user/%s/temp/skin/date"
+
+	// VitalsMaxRange is the largest number of days that can be requested at once
+	VitalsMaxRange = 30 * 24 * time.Hour
+)
+
+// VitalsOptions are shared by the nightly vitals endpoints which all only support date ranges
+type VitalsOptions struct {
+	StartDate *time.Time
+	EndDate   *time.Time
+}
+
+type SpO2OverView struct {
+	Date  string    `json:"dateTime"`
+	Value SpO2Value `json:"value"`
+}
+
+type SpO2Value struct {
+	Avg float64 `json:"avg"`
+	Min float64 `json:"min"`
+	Max float64 `json:"max"`
+}
+
+type SpO2IntraDay struct {
+	Date    string       `json:"dateTime"`
+	Minutes []SpO2Minute `json:"minutes"`
+}
+
+type SpO2Minute struct {
+	Minute string  `json:"minute"`
+	Value  float64 `json:"value"`
+}
+
+type BreathingRateData struct {
+	OverviewByDay []BreathingRateOverView `json:"br"`
+}
+
+type BreathingRateOverView struct {
+	Date  string             `json:"dateTime"`
+	Value BreathingRateValue `json:"value"`
+}
+
+type BreathingRateValue struct {
+	BreathingRate float64 `json:"breathingRate"`
+}
+
+type SkinTempData struct {
+	OverviewByDay []SkinTempOverView `json:"tempSkin"`
+}
+
+type SkinTempOverView struct {
+	Date    string        `json:"dateTime"`
+	LogType string        `json:"logType"`
+	Value   SkinTempValue `json:"value"`
+}
+
+type SkinTempValue struct {
+	NightlyRelative float64 `json:"nightlyRelative"`
+}
+
+func (c *Client) GetSpO2(user string, opts VitalsOptions) ([]SpO2OverView, error) {
+	// Always request a range as a single day returns an object rather than a list
+	if opts.StartDate != nil && opts.EndDate == nil {
+		opts.EndDate = opts.StartDate
+	}
+
+	path, err := opts.toPath(spo2Path, user, "")
+	if err != nil {
+		return nil, err
+	}
+
+	userClient, err := c.GetUser(user)
+	if err != nil {
+		return nil, err
+	}
+
+	data := make([]SpO2OverView, 0)
+	if err := c.get(userClient.httpClient, path, &data); err != nil {
+		return nil, err
+	}
+
+	return data, nil
+}
+
+func (c *Client) GetSpO2IntraDay(user string, date time.Time) (*SpO2IntraDay, error) {
+	path, err := VitalsOptions{StartDate: &date}.toPath(spo2Path, user, "/all")
+	if err != nil {
+		return nil, err
+	}
+
+	userClient, err := c.GetUser(user)
+	if err != nil {
+		return nil, err
+	}
+
+	data := &SpO2IntraDay{}
+	if err := c.get(userClient.httpClient, path, data); err != nil {
+		return nil, err
+	}
+
+	return data, nil
+}
+
+func (c *Client) GetBreathingRate(user string, opts VitalsOptions) (*BreathingRateData, error) {
+	path, err := opts.toPath(breathingRatePath, user, "")
+	if err != nil {
+		return nil, err
+	}
+
+	userClient, err := c.GetUser(user)
+	if err != nil {
+		return nil, err
+	}
+
+	data := &BreathingRateData{}
+	if err := c.get(userClient.httpClient, path, data); err != nil {
+		return nil, err
+	}
+
+	return data, nil
+}
+
+func (c *Client) GetSkinTemp(user string, opts VitalsOptions) (*SkinTempData, error) {
+	path, err := opts.toPath(skinTempPath, user, "")
+	if err != nil {
+		return nil, err
+	}
+
+	userClient, err := c.GetUser(user)
+	if err != nil {
+		return nil, err
+	}
+
+	data := &SkinTempData{}
+	if err := c.get(userClient.httpClient, path, data); err != nil {
+		return nil, err
+	}
+
+	return data, nil
+}
+
+func (o VitalsOptions) toPath(resourcePath, user, suffix string) (string, error) {
+	path := basePath + fmt.Sprintf(resourcePath, user)
+
+	if o.StartDate == nil {
+		path += "/today"
+	} else {
+		path += "/" + o.StartDate.Format("2006-01-02")
+	}
+
+	// Vitals ranges are limited to 30 days by the api
+	if o.EndDate != nil {
+		if o.StartDate == nil {
+			return "", fmt.Errorf("end date given without a start date")
+		}
+		if o.EndDate.Sub(*o.StartDate) > VitalsMaxRange {
+			return "", fmt.Errorf("date range can not exceed 30 days")
+		}
+		path += "/" + o.EndDate.Format("2006-01-02")
+	}
+
+	path += suffix + ".json"
+
+	return path, nil
+}
+
+func (u *User) SaveSpO2Data(db *sql.DB, data []SpO2OverView) error {
+	for _, day := range data {
+		if day.Value.Avg == 0 {
+			continue
+		}
+
+		_, err := db.Exec(
+			`insert into spo2_daily (user_id, date, avg, min, max) values (?, ?, ?, ?, ?)
+			on duplicate key update avg = values(avg), min = values(min), max = values(max)`,
+			u.ID,
+			day.Date,
+			day.Value.Avg,
+			day.Value.Min,
+			day.Value.Max,
+		)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (u *User) SaveSpO2IntraDay(db *sql.DB, data *SpO2IntraDay) error {
+	if len(data.Minutes) == 0 {
+		return nil
+	}
+
+	values := make([]string, 0, len(data.Minutes))
+	for _, m := range data.Minutes {
+		date, err := formatFitbitDateTime(m.Minute)
+		if err != nil {
+			return err
+		}
+		values = append(values, fmt.Sprintf("('%s', '%s', %f)", u.ID, date, m.Value))
+	}
+
+	// Insert 200 data points at a time to help take load off the database connection, corrected samples replace
+	// the saved ones
+	insertQuery := "insert into spo2_intraday (user_id, date, value) values "
+	for i := 0; i < len(values); i += 200 {
+		end := i + 200
+		if end > len(values) {
+			end = len(values)
+		}
+		if _, err := db.Exec(insertQuery + strings.Join(values[i:end], ", ") + " on duplicate key update value = values(value)"); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (u *User) SaveBreathingRateData(db *sql.DB, data *BreathingRateData) error {
+	for _, day := range data.OverviewByDay {
+		if day.Value.BreathingRate == 0 {
+			continue
+		}
+
+		_, err := db.Exec(
+			"insert into breathing_rate (user_id, date, value) values (?, ?, ?) on duplicate key update value = values(value)",
+			u.ID,
+			day.Date,
+			day.Value.BreathingRate,
+		)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (u *User) SaveSkinTempData(db *sql.DB, data *SkinTempData) error {
+	for _, day := range data.OverviewByDay {
+		_, err := db.Exec(
+			`insert into skin_temp (user_id, date, nightly_relative, log_type) values (?, ?, ?, ?)
+			on duplicate key update nightly_relative = values(nightly_relative), log_type = values(log_type)`,
+			u.ID,
+			day.Date,
+			day.Value.NightlyRelative,
+			day.LogType,
+		)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
@@ -3,10 +3,10 @@ package webserver
 import (
 	"errors"
 	"fmt"
+	"html/template"
 	"math"
 	"net/http"
 	"strings"
-	"text/template"
 	"time"
 
 	"github.com/bah2830/fitbit-exporter/pkg/fitbit"
@@ -14,12 +14,25 @@ import (
 )
 
 type indexData struct {
-	BackfillerRunning bool             `json:"backfillerRunning"`
-	BackfillerLastRun time.Time        `json:"backfillerLastRun,omitempty"`
-	Last7DaysZones    *zones           `json:"last7DaysZones,omitempty"`
-	Last30DaysZones   *zones           `json:"last30DaysZones,omitempty"`
-	PersonalRecords   *personalRecords `json:"personalRecords,omitempty"`
-	CurrentDay        *currentDay      `json:"currentDay,omitempty"`
+	BaseURL           string                    `json:"-"`
+	UserID            string                    `json:"-"`
+	RemoveEnabled     bool                      `json:"-"`
+	BackfillerRunning bool                      `json:"backfillerRunning"`
+	BackfillerLastRun time.Time                 `json:"backfillerLastRun,omitempty"`
+	Last7DaysZones    *zones                    `json:"last7DaysZones,omitempty"`
+	Last30DaysZones   *zones                    `json:"last30DaysZones,omitempty"`
+	Last7DaysSteps    []fitbit.ActivityOverview `json:"last7DaysSteps,omitempty"`
+	Last30DaysWeight  []fitbit.WeightLog        `json:"last30DaysWeight,omitempty"`
+	Devices           []fitbit.Device           `json:"devices,omitempty"`
+	Last7DaysAZM      *activeZoneMinutes        `json:"last7DaysAZM,omitempty"`
+	Last30DaysAZM     *activeZoneMinutes        `json:"last30DaysAZM,omitempty"`
+	ECGReadings       []fitbit.ECGReading       `json:"ecgReadings,omitempty"`
+	IRNAlerts         []fitbit.IRNAlert         `json:"irnAlerts,omitempty"`
+	Lifetime          *fitbit.LifetimeStats     `json:"lifetime,omitempty"`
+	RateLimit         *fitbit.RateLimitStatus   `json:"rateLimit,omitempty"`
+	SyncStates        []fitbit.SyncState        `json:"syncStates,omitempty"`
+	PersonalRecords   *personalRecords          `json:"personalRecords,omitempty"`
+	CurrentDay        *currentDay               `json:"currentDay,omitempty"`
 }
 
 type currentDay struct {
@@ -28,13 +41,26 @@ type currentDay struct {
 	Low        *fitbit.HeartData  `json:"low,omitempty"`
 	Zones      *zones             `json:"zones,omitempty"`
 	HeartRates []fitbit.HeartData `json:"heartRates,omitempty"`
+	Activity   *activity          `json:"activity,omitempty"`
+	AZM        *activeZoneMinutes `json:"azm,omitempty"`
+}
+
+type activity struct {
+	Steps     float64 `json:"steps"`
+	Distance  float64 `json:"distance"`
+	Floors    float64 `json:"floors"`
+	Elevation float64 `json:"elevation"`
+	Calories  float64 `json:"calories"`
 }
 
 type personalRecords struct {
-	Top10HeartRates    []fitbit.HeartData `json:"top10HeartRates,omitempty"`
-	Bottom10HeartRates []fitbit.HeartData `json:"bottom10HeartRates,omitempty"`
-	MinResting         *fitbit.HeartData  `json:"minResting,omitempty"`
-	MaxResting         *fitbit.HeartData  `json:"maxResting,omitempty"`
+	Top10HeartRates    []fitbit.HeartData  `json:"top10HeartRates,omitempty"`
+	Bottom10HeartRates []fitbit.HeartData  `json:"bottom10HeartRates,omitempty"`
+	MinResting         *fitbit.HeartData   `json:"minResting,omitempty"`
+	MaxResting         *fitbit.HeartData   `json:"maxResting,omitempty"`
+	MaxVO2Max          *fitbit.CardioScore `json:"maxVO2Max,omitempty"`
+	MinVO2Max          *fitbit.CardioScore `json:"minVO2Max,omitempty"`
+	RecentBadges       []fitbit.Badge      `json:"recentBadges,omitempty"`
 
 	MostOutOfRange *zone `json:"mostOutOfRange,omitempty"`
 	MostFatBurn    *zone `json:"mostFatBurn,omitempty"`
@@ -42,6 +68,15 @@ type personalRecords struct {
 	MostPeak       *zone `json:"mostPeak,omitempty"`
 }
 
+// activeZoneMinutes holds the credited zone minutes next to the heart rate zones for the same period
+type activeZoneMinutes struct {
+	Total      int    `json:"total"`
+	FatBurn    int    `json:"fatBurn"`
+	Cardio     int    `json:"cardio"`
+	Peak       int    `json:"peak"`
+	HeartZones *zones `json:"heartZones,omitempty"`
+}
+
 type zones struct {
 	OutOfRange zone `json:"outOfRange,omitempty"`
 	FatBurn    zone `json:"fatBurn,omitempty"`
@@ -124,17 +159,107 @@ func (s *Server) userHandler(w http.ResponseWriter, r *http.Request) {
 		writeErr(w, http.StatusInternalServerError, fmt.Errorf("GetMaxZones: "+err.Error()))
 		return
 	}
+	ecgReadings, err := s.client.GetLatestECGReadings(user, 10)
+	if err != nil {
+		writeErr(w, http.StatusInternalServerError, fmt.Errorf("GetLatestECGReadings: "+err.Error()))
+		return
+	}
+	irnAlerts, err := s.client.GetLatestIRNAlerts(user, 10)
+	if err != nil {
+		writeErr(w, http.StatusInternalServerError, fmt.Errorf("GetLatestIRNAlerts: "+err.Error()))
+		return
+	}
+	lifetime, err := s.client.GetCurrentLifetimeStats(user)
+	if err != nil {
+		writeErr(w, http.StatusInternalServerError, fmt.Errorf("GetCurrentLifetimeStats: "+err.Error()))
+		return
+	}
+	recentBadges, err := s.client.GetRecentBadges(user, time.Now().Add(-30*24*time.Hour))
+	if err != nil {
+		writeErr(w, http.StatusInternalServerError, fmt.Errorf("GetRecentBadges: "+err.Error()))
+		return
+	}
+	topVO2Max, err := s.client.GetVO2Max(user, true)
+	if err != nil {
+		writeErr(w, http.StatusInternalServerError, fmt.Errorf("GetVO2Max: "+err.Error()))
+		return
+	}
+	bottomVO2Max, err := s.client.GetVO2Max(user, false)
+	if err != nil {
+		writeErr(w, http.StatusInternalServerError, fmt.Errorf("GetVO2Max: "+err.Error()))
+		return
+	}
+	currentDayActivity, err := s.client.GetCurrentDayActivity(user)
+	if err != nil {
+		writeErr(w, http.StatusInternalServerError, fmt.Errorf("GetCurrentDayActivity: "+err.Error()))
+		return
+	}
+	last7DaysSteps, err := s.client.GetActivityByDate(user, fitbit.ActivityResourceSteps, time.Now().Add(-7*24*time.Hour), time.Now())
+	if err != nil {
+		writeErr(w, http.StatusInternalServerError, fmt.Errorf("GetActivityByDate: "+err.Error()))
+		return
+	}
+	last30DaysWeight, err := s.client.GetWeightByDate(user, time.Now().Add(-30*24*time.Hour), time.Now())
+	if err != nil {
+		writeErr(w, http.StatusInternalServerError, fmt.Errorf("GetWeightByDate: "+err.Error()))
+		return
+	}
+	devices, err := s.client.GetStoredDevices(user)
+	if err != nil {
+		writeErr(w, http.StatusInternalServerError, fmt.Errorf("GetStoredDevices: "+err.Error()))
+		return
+	}
+	currentDayAZM, err := s.client.GetAZMTotalsByDate(user, time.Now(), time.Now())
+	if err != nil {
+		writeErr(w, http.StatusInternalServerError, fmt.Errorf("GetAZMTotalsByDate: "+err.Error()))
+		return
+	}
+	last7DaysAZM, err := s.client.GetAZMTotalsByDate(user, time.Now().Add(-7*24*time.Hour), time.Now())
+	if err != nil {
+		writeErr(w, http.StatusInternalServerError, fmt.Errorf("GetAZMTotalsByDate: "+err.Error()))
+		return
+	}
+	last30DaysAZM, err := s.client.GetAZMTotalsByDate(user, time.Now().Add(-30*24*time.Hour), time.Now())
+	if err != nil {
+		writeErr(w, http.StatusInternalServerError, fmt.Errorf("GetAZMTotalsByDate: "+err.Error()))
+		return
+	}
+
+	syncStates, err := s.client.GetSyncStates(user)
+	if err != nil {
+		writeErr(w, http.StatusInternalServerError, fmt.Errorf("GetSyncStates: "+err.Error()))
+		return
+	}
+
+	// Users that have been removed no longer have a budget but their saved data can still be shown
+	rateLimit, _ := s.client.RateLimit(user)
 
 	data := indexData{
-		BackfillerRunning: s.exporter.BackfillRunning,
-		BackfillerLastRun: s.exporter.BackfillLastRun,
+		BaseURL:           s.cfg.PublicURL(""),
+		UserID:            user,
+		RemoveEnabled:     s.cfg.WebFrontend.AdminToken != "",
+		BackfillerRunning: s.exporter.BackfillRunning(),
+		BackfillerLastRun: s.exporter.BackfillLastRun(),
 		Last7DaysZones:    zonesToPercentages(last7DaysZones),
 		Last30DaysZones:   zonesToPercentages(last30DaysZones),
+		Last7DaysSteps:    last7DaysSteps,
+		Last30DaysWeight:  last30DaysWeight,
+		Devices:           devices,
+		Last7DaysAZM:      toActiveZoneMinutes(last7DaysAZM, last7DaysZones),
+		Last30DaysAZM:     toActiveZoneMinutes(last30DaysAZM, last30DaysZones),
+		ECGReadings:       ecgReadings,
+		IRNAlerts:         irnAlerts,
+		Lifetime:          lifetime,
+		RateLimit:         rateLimit,
+		SyncStates:        syncStates,
 		PersonalRecords: &personalRecords{
 			Top10HeartRates:    top10Hr,
 			Bottom10HeartRates: bottom10Hr,
 			MaxResting:         topResting,
 			MinResting:         bottomResting,
+			MaxVO2Max:          topVO2Max,
+			MinVO2Max:          bottomVO2Max,
+			RecentBadges:       recentBadges,
 			MostOutOfRange:     fitbitZoneToZone(maxZones["Out of Range"]),
 			MostFatBurn:        fitbitZoneToZone(maxZones["Fat Burn"]),
 			MostCardio:         fitbitZoneToZone(maxZones["Cardio"]),
@@ -146,6 +271,14 @@ func (s *Server) userHandler(w http.ResponseWriter, r *http.Request) {
 			High:       currentHigh,
 			Low:        currentLow,
 			Zones:      zonesToPercentages(currentDayZones),
+			Activity: &activity{
+				Steps:     currentDayActivity[fitbit.ActivityResourceSteps],
+				Distance:  currentDayActivity[fitbit.ActivityResourceDistance],
+				Floors:    currentDayActivity[fitbit.ActivityResourceFloors],
+				Elevation: currentDayActivity[fitbit.ActivityResourceElevation],
+				Calories:  currentDayActivity[fitbit.ActivityResourceCalories],
+			},
+			AZM: toActiveZoneMinutes(currentDayAZM, currentDayZones),
 		},
 	}
 
@@ -209,3 +342,13 @@ func fitbitZoneToZone(z fitbit.HeartRateZone) *zone {
 		Calories: z.CaloriesOut,
 	}
 }
+
+func toActiveZoneMinutes(azm *fitbit.AZMValue, heartZones []fitbit.HeartRateZone) *activeZoneMinutes {
+	return &activeZoneMinutes{
+		Total:      azm.ActiveZoneMinutes,
+		FatBurn:    azm.FatBurn,
+		Cardio:     azm.Cardio,
+		Peak:       azm.Peak,
+		HeartZones: zonesToPercentages(heartZones),
+	}
+}
@@ -2,22 +2,33 @@ package webserver
 
 import (
 	"compress/gzip"
+	"crypto/subtle"
 	"encoding/json"
+	"errors"
+	"html/template"
 	"io"
+	"io/ioutil"
 	"log"
 	"net/http"
+	"net/url"
 	"strings"
-	"text/template"
 	"time"
 
 	"github.com/bah2830/fitbit-exporter/pkg/config"
 	"github.com/bah2830/fitbit-exporter/pkg/exporter"
 	"github.com/bah2830/fitbit-exporter/pkg/fitbit"
 	"github.com/gorilla/mux"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
 )
 
 type frontendErr struct {
-	Error string
+	Error   string
+	BaseURL string `json:"-"`
+}
+
+type indexPage struct {
+	BaseURL string
+	Users   []*fitbit.User
 }
 
 type Server struct {
@@ -35,16 +46,27 @@ func New(cfg *config.Config, client *fitbit.Client, exporter *exporter.Exporter)
 }
 
 func (s *Server) Start() error {
-	r := mux.NewRouter().StrictSlash(true)
+	root := mux.NewRouter().StrictSlash(true)
+
+	// Serve everything under the path of the public url so a reverse proxy doesn't need to rewrite paths
+	prefix := s.cfg.PublicPath()
+	r := root
+	if prefix != "/" {
+		r = root.PathPrefix(strings.TrimSuffix(prefix, "/")).Subrouter()
+	}
+
 	r.HandleFunc("/", gzipHandler(s.indexHandler))
 	r.HandleFunc("/login", s.client.LoginHandler)
-	r.HandleFunc("/callback", s.client.CallbackHandler)
+	r.HandleFunc("/callback", s.callbackHandler)
+	r.Handle("/metrics", promhttp.Handler())
 	r.HandleFunc("/favicon.ico", func(w http.ResponseWriter, r *http.Request) {})
-	r.Handle("/assets/", http.StripPrefix("/assets/", http.FileServer(http.Dir("frontend/assets"))))
+	r.PathPrefix("/assets/").Handler(http.StripPrefix(prefix+"assets/", http.FileServer(http.Dir("frontend/assets"))))
+	r.HandleFunc("/webhook/fitbit", s.webhookHandler).Methods(http.MethodGet, http.MethodPost)
+	r.HandleFunc("/{user}/remove", s.removeUserHandler).Methods(http.MethodPost)
 	r.HandleFunc("/{user}", gzipHandler(s.userHandler))
-	http.Handle("/", r)
+	http.Handle("/", root)
 
-	log.Println("listening on " + s.cfg.WebFrontend.Listen)
+	log.Println("listening on " + s.cfg.WebFrontend.Listen + " as " + s.cfg.PublicURL(""))
 	go http.ListenAndServe(s.cfg.WebFrontend.Listen, nil)
 	return nil
 }
@@ -68,6 +90,19 @@ func writeErr(w http.ResponseWriter, statueCode int, err error) {
 	w.Write(body)
 }
 
+// writeErrPage renders the error page for requests made by a browser rather than returning json
+func (s *Server) writeErrPage(w http.ResponseWriter, statusCode int, err error) {
+	t, tmplErr := template.New("error.template.html").ParseFiles("frontend/templates/error.template.html")
+	if tmplErr != nil {
+		writeErr(w, statusCode, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/html")
+	w.WriteHeader(statusCode)
+	t.Execute(w, frontendErr{Error: err.Error(), BaseURL: s.cfg.PublicURL("")})
+}
+
 func gzipHandler(fn http.HandlerFunc) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		if !strings.Contains(r.Header.Get("Accept-Encoding"), "gzip") {
@@ -105,5 +140,114 @@ func (s *Server) indexHandler(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	t.Execute(w, s.client.Users)
+	t.Execute(w, indexPage{BaseURL: s.cfg.PublicURL(""), Users: s.client.GetUsers()})
+}
+
+func (s *Server) callbackHandler(w http.ResponseWriter, r *http.Request) {
+	user, err := s.client.HandleCallback(w, r)
+	if err != nil {
+		if _, ok := err.(*fitbit.AuthError); ok {
+			s.writeErrPage(w, http.StatusBadRequest, err)
+			return
+		}
+		s.writeErrPage(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	s.exporter.SyncUser(user)
+	http.Redirect(w, r, s.cfg.PublicURL(user.ID), http.StatusTemporaryRedirect)
+}
+
+func (s *Server) removeUserHandler(w http.ResponseWriter, r *http.Request) {
+	// Only accept the form from our own pages so another site can't disconnect users
+	if !s.sameOrigin(r) {
+		s.writeErrPage(w, http.StatusForbidden, errors.New("cross origin request rejected"))
+		return
+	}
+
+	// Removing a user revokes their token and can delete all their data so it needs the configured secret
+	adminToken := s.cfg.WebFrontend.AdminToken
+	if adminToken == "" {
+		s.writeErrPage(w, http.StatusNotFound, errors.New("removing users is disabled"))
+		return
+	}
+	if subtle.ConstantTimeCompare([]byte(r.FormValue("token")), []byte(adminToken)) != 1 {
+		s.writeErrPage(w, http.StatusForbidden, errors.New("invalid admin token"))
+		return
+	}
+
+	userID := mux.Vars(r)["user"]
+	purge := r.FormValue("purge") == "true"
+	if err := s.client.RemoveUser(userID, purge); err != nil {
+		statusCode := http.StatusInternalServerError
+		if errors.Is(err, fitbit.ErrUserNotFound) {
+			statusCode = http.StatusNotFound
+		}
+		s.writeErrPage(w, statusCode, err)
+		return
+	}
+
+	http.Redirect(w, r, s.cfg.PublicURL(""), http.StatusSeeOther)
+}
+
+// sameOrigin reports if the request came from a page served under the public url
+func (s *Server) sameOrigin(r *http.Request) bool {
+	source := r.Header.Get("Origin")
+	if source == "" {
+		source = r.Header.Get("Referer")
+	}
+	u, err := url.Parse(source)
+	if err != nil || u.Host == "" {
+		return false
+	}
+
+	public, err := url.Parse(s.cfg.PublicURL(""))
+	if err != nil {
+		return false
+	}
+
+	return u.Scheme == public.Scheme && u.Host == public.Host
+}
+
+// maxNotificationSize limits the body read from the webhook, fitbit sends small batches of notifications
+const maxNotificationSize = 1 << 20
+
+// webhookHandler receives subscription notifications from fitbit. Fitbit checks the endpoint with the
+// verification code before using it and expects notifications to be answered within a few seconds.
+func (s *Server) webhookHandler(w http.ResponseWriter, r *http.Request) {
+	subscriptions := s.cfg.Fitbit.Subscriptions
+	if !subscriptions.Enabled {
+		w.WriteHeader(http.StatusNotFound)
+		return
+	}
+
+	if r.Method == http.MethodGet {
+		if r.URL.Query().Get("verify") != subscriptions.VerificationCode {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		w.WriteHeader(http.StatusNoContent)
+		return
+	}
+
+	body, err := ioutil.ReadAll(io.LimitReader(r.Body, maxNotificationSize))
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+
+	notifications, err := s.client.ParseNotifications(body, r.Header.Get("X-Fitbit-Signature"))
+	if err != nil {
+		log.Println("rejected fitbit notification: " + err.Error())
+		// Fitbit expects a not found response for notifications with an invalid signature
+		if err == fitbit.ErrInvalidSignature {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+
+	s.exporter.Notify(notifications)
+	w.WriteHeader(http.StatusNoContent)
 }